@@ -0,0 +1,72 @@
+// Command gogen writes Go functions computing the given expressions, for use with go generate:
+//
+//	//go:generate go run symbolic-algebra/cmd/gogen -name Force -vars x,y -o force_gen.go "x ^ 2 * sin(y)"
+//
+// A single expression gives func Force(x, y float64) float64, several write into an out slice.
+// With -grad the function computes the gradient of the single expression along -vars instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	symb "symbolic-algebra/pkg/symbolic"
+)
+
+func main() {
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package of the generated file, $GOPACKAGE by default")
+	name := flag.String("name", "", "name of the generated function")
+	vars := flag.String("vars", "", "comma separated parameters of the function, in order")
+	output := flag.String("o", "", "output file, standard output if empty")
+	grad := flag.Bool("grad", false, "generate the gradient of the expression")
+	flag.Parse()
+
+	if err := run(*pkg, *name, *vars, *output, *grad, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "gogen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(pkg, name, vars, output string, grad bool, inputs []string) error {
+	if pkg == "" {
+		pkg = "main"
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("no expressions given")
+	}
+	f := symb.GoFunction{Name: name}
+	for _, v := range strings.Split(vars, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			f.Vars = append(f.Vars, symb.CreateVariable(v))
+		}
+	}
+	for _, input := range inputs {
+		expr, err := symb.ParseWith(input, f.Vars...)
+		if err != nil {
+			return err
+		}
+		f.Exprs = append(f.Exprs, expr.Trim())
+	}
+	if grad {
+		if len(f.Exprs) != 1 {
+			return fmt.Errorf("-grad takes a single expression")
+		}
+		expr := f.Exprs[0]
+		f.Exprs = nil
+		for _, v := range f.Vars {
+			f.Exprs = append(f.Exprs, expr.Diff(v).Trim())
+		}
+	}
+
+	source, err := symb.GenerateGo(pkg, f)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(output, source, 0o644)
+}
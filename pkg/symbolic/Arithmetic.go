@@ -15,6 +15,18 @@ func (a *add) Evaluate() float64 {
 	return a.left.Evaluate() + a.right.Evaluate()
 }
 
+func (a *add) EvaluateErr() (float64, error) {
+	return evaluateErr(a)
+}
+
+func (a *add) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(a, env)
+}
+
+func (a *add) apply(left, right float64) (float64, error) {
+	return left + right, nil
+}
+
 func (a *add) Diff(v *Variable) Evaluatable {
 	leftIsFunc := a.left.FunctionOf(v)
 	rightIsFunc := a.right.FunctionOf(v)
@@ -33,20 +45,33 @@ func (a *add) String() string {
 	return "(" + a.left.String() + " + " + a.right.String() + ")"
 }
 
+func (a *add) symbol() string {
+	return "+"
+}
+
+func (a *add) rebuild(left, right Evaluatable) Evaluatable {
+	return NodeAdd(left, right)
+}
+
 func (a *add) Trim() Evaluatable {
+	// Fold a sum of numeric constants
 	// Drop a sum with zero
+	// Absorb a negation: a + (-b) = a - b and (-a) + b = b - a
 	leftTrim := a.left.Trim()
 	rightTrim := a.right.Trim()
+	if lValue, rValue, ok := literalValues(leftTrim, rightTrim); ok {
+		return GetConstantValue(lValue + rValue)
+	}
 
 	dropLeft := false
-	if leftTrim.IsConstant() {
-		if leftTrim.Evaluate() == 0.0 {
+	if cValue, ok := constantValue(leftTrim); ok {
+		if cValue == 0.0 {
 			dropLeft = true
 		}
 	}
 	dropRight := false
-	if rightTrim.IsConstant() {
-		if rightTrim.Evaluate() == 0.0 {
+	if cValue, ok := constantValue(rightTrim); ok {
+		if cValue == 0.0 {
 			dropRight = true
 		}
 	}
@@ -57,6 +82,10 @@ func (a *add) Trim() Evaluatable {
 		return rightTrim
 	} else if dropRight {
 		return leftTrim
+	} else if n, ok := rightTrim.(*neg); ok {
+		return NodeSub(leftTrim, n.left)
+	} else if n, ok := leftTrim.(*neg); ok {
+		return NodeSub(rightTrim, n.left)
 	} else {
 		return NodeAdd(leftTrim, rightTrim)
 	}
@@ -77,6 +106,18 @@ func (s *sub) Evaluate() float64 {
 	return s.left.Evaluate() - s.right.Evaluate()
 }
 
+func (s *sub) EvaluateErr() (float64, error) {
+	return evaluateErr(s)
+}
+
+func (s *sub) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(s, env)
+}
+
+func (s *sub) apply(left, right float64) (float64, error) {
+	return left - right, nil
+}
+
 func (s *sub) Diff(v *Variable) Evaluatable {
 	leftIsFunc := s.left.FunctionOf(v)
 	rightIsFunc := s.right.FunctionOf(v)
@@ -85,7 +126,7 @@ func (s *sub) Diff(v *Variable) Evaluatable {
 	} else if leftIsFunc {
 		return s.left.Diff(v)
 	} else if rightIsFunc {
-		return NodeMultiply(GetConstant(ConstantMinusOne), s.right.Diff(v))
+		return NodeNeg(s.right.Diff(v))
 	} else {
 		return GetConstant(ConstantZero)
 	}
@@ -95,20 +136,33 @@ func (s *sub) String() string {
 	return "(" + s.left.String() + " - " + s.right.String() + ")"
 }
 
+func (s *sub) symbol() string {
+	return "-"
+}
+
+func (s *sub) rebuild(left, right Evaluatable) Evaluatable {
+	return NodeSub(left, right)
+}
+
 func (s *sub) Trim() Evaluatable {
-	// Drop a sub with zero
+	// Fold a sub of numeric constants
+	// Drop a sub with zero, negating from zero
+	// Absorb a negation: a - (-b) = a + b
 	leftTrim := s.left.Trim()
 	rightTrim := s.right.Trim()
+	if lValue, rValue, ok := literalValues(leftTrim, rightTrim); ok {
+		return GetConstantValue(lValue - rValue)
+	}
 
 	dropLeft := false
-	if leftTrim.IsConstant() {
-		if leftTrim.Evaluate() == 0.0 {
+	if cValue, ok := constantValue(leftTrim); ok {
+		if cValue == 0.0 {
 			dropLeft = true
 		}
 	}
 	dropRight := false
-	if rightTrim.IsConstant() {
-		if rightTrim.Evaluate() == 0.0 {
+	if cValue, ok := constantValue(rightTrim); ok {
+		if cValue == 0.0 {
 			dropRight = true
 		}
 	}
@@ -116,9 +170,11 @@ func (s *sub) Trim() Evaluatable {
 	if dropLeft && dropRight {
 		return GetConstant(ConstantZero)
 	} else if dropLeft {
-		return rightTrim
+		return negate(rightTrim)
 	} else if dropRight {
 		return leftTrim
+	} else if n, ok := rightTrim.(*neg); ok {
+		return NodeAdd(leftTrim, n.left)
 	} else {
 		return NodeSub(leftTrim, rightTrim)
 	}
@@ -139,6 +195,18 @@ func (m *multiply) Evaluate() float64 {
 	return m.left.Evaluate() * m.right.Evaluate()
 }
 
+func (m *multiply) EvaluateErr() (float64, error) {
+	return evaluateErr(m)
+}
+
+func (m *multiply) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(m, env)
+}
+
+func (m *multiply) apply(left, right float64) (float64, error) {
+	return left * right, nil
+}
+
 func (m *multiply) Diff(v *Variable) Evaluatable {
 	leftIsFunc := m.left.FunctionOf(v)
 	rightIsFunc := m.right.FunctionOf(v)
@@ -160,31 +228,47 @@ func (m *multiply) String() string {
 	return "(" + m.left.String() + " * " + m.right.String() + ")"
 }
 
+func (m *multiply) symbol() string {
+	return "*"
+}
+
+func (m *multiply) rebuild(left, right Evaluatable) Evaluatable {
+	return NodeMultiply(left, right)
+}
+
 func (m *multiply) Trim() Evaluatable {
+	// Folds a multiply of numeric constants
 	// Kills a multiply with zero
-	// Simplifies a multiply with one
+	// Simplifies a multiply with one, negates a multiply with minus one
 	leftTrim := m.left.Trim()
 	rightTrim := m.right.Trim()
+	if lValue, rValue, ok := literalValues(leftTrim, rightTrim); ok {
+		return GetConstantValue(lValue * rValue)
+	}
 
 	// Boolean flags:
 	leftIsZero := false
 	rightIsZero := false
 	leftIsOne := false
 	rightIsOne := false
-	if leftTrim.IsConstant() {
-		cValue := leftTrim.Evaluate()
+	leftIsMinusOne := false
+	rightIsMinusOne := false
+	if cValue, ok := constantValue(leftTrim); ok {
 		if cValue == 0.0 {
 			leftIsZero = true
 		} else if cValue == 1.0 {
 			leftIsOne = true
+		} else if cValue == -1.0 {
+			leftIsMinusOne = true
 		}
 	}
-	if rightTrim.IsConstant() {
-		cValue := rightTrim.Evaluate()
+	if cValue, ok := constantValue(rightTrim); ok {
 		if cValue == 0.0 {
 			rightIsZero = true
 		} else if cValue == 1.0 {
 			rightIsOne = true
+		} else if cValue == -1.0 {
+			rightIsMinusOne = true
 		}
 	}
 
@@ -195,6 +279,10 @@ func (m *multiply) Trim() Evaluatable {
 		return rightTrim
 	} else if rightIsOne {
 		return leftTrim
+	} else if leftIsMinusOne {
+		return negate(rightTrim)
+	} else if rightIsMinusOne {
+		return negate(leftTrim)
 	} else {
 		return NodeMultiply(leftTrim, rightTrim)
 	}
@@ -220,6 +308,21 @@ func (d *divide) Evaluate() float64 {
 	}
 }
 
+func (d *divide) EvaluateErr() (float64, error) {
+	return evaluateErr(d)
+}
+
+func (d *divide) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(d, env)
+}
+
+func (d *divide) apply(left, right float64) (float64, error) {
+	if right == 0.0 {
+		return 0.0, ErrDivisionByZero
+	}
+	return left / right, nil
+}
+
 func (d *divide) Diff(v *Variable) Evaluatable {
 	leftIsFunc := d.left.FunctionOf(v)
 	rightIsFunc := d.right.FunctionOf(v)
@@ -236,7 +339,7 @@ func (d *divide) Diff(v *Variable) Evaluatable {
 	} else if rightIsFunc {
 		return NodeDivide(
 			NodeMultiply(
-				NodeMultiply(GetConstant(ConstantMinusOne), d.left),
+				NodeNeg(d.left),
 				d.right.Diff(v),
 			),
 			NodeMultiply(d.right, d.right),
@@ -250,9 +353,18 @@ func (d *divide) String() string {
 	return "(" + d.left.String() + " / " + d.right.String() + ")"
 }
 
+func (d *divide) symbol() string {
+	return "/"
+}
+
+func (d *divide) rebuild(left, right Evaluatable) Evaluatable {
+	return NodeDivide(left, right)
+}
+
 func (d *divide) Trim() Evaluatable {
 	// Kills a numerator equal to zero
 	// Simplify a one denominator
+	// Folds a division of numeric constants
 	// Panic division by zero
 	leftTrim := d.left.Trim()
 	rightTrim := d.right.Trim()
@@ -261,14 +373,12 @@ func (d *divide) Trim() Evaluatable {
 	numIsZero := false
 	denIsZero := false
 	denIsOne := false
-	if leftTrim.IsConstant() {
-		cValue := leftTrim.Evaluate()
+	if cValue, ok := constantValue(leftTrim); ok {
 		if cValue == 0.0 {
 			numIsZero = true
 		}
 	}
-	if rightTrim.IsConstant() {
-		cValue := rightTrim.Evaluate()
+	if cValue, ok := constantValue(rightTrim); ok {
 		if cValue == 0.0 {
 			denIsZero = true
 		} else if cValue == 1.0 {
@@ -283,7 +393,77 @@ func (d *divide) Trim() Evaluatable {
 		return GetConstant(ConstantZero)
 	} else if denIsOne {
 		return leftTrim
+	} else if lValue, rValue, ok := literalValues(leftTrim, rightTrim); ok {
+		return GetConstantValue(lValue / rValue)
 	} else {
 		return NodeDivide(leftTrim, rightTrim)
 	}
 }
+
+// neg operation node
+type neg struct {
+	node
+}
+
+// Returns a Neg Node given the operand: -left
+func NodeNeg(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &neg{parent}
+}
+
+func (n *neg) Evaluate() float64 {
+	return -n.left.Evaluate()
+}
+
+func (n *neg) EvaluateErr() (float64, error) {
+	return evaluateErr(n)
+}
+
+func (n *neg) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(n, env)
+}
+
+func (n *neg) apply(left, _ float64) (float64, error) {
+	return -left, nil
+}
+
+func (n *neg) Diff(v *Variable) Evaluatable {
+	isFunc := n.left.FunctionOf(v)
+	if isFunc {
+		return NodeNeg(n.left.Diff(v))
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (n *neg) String() string {
+	return "(-" + n.left.String() + ")"
+}
+
+func (n *neg) symbol() string {
+	return "neg"
+}
+
+func (n *neg) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeNeg(left)
+}
+
+func (n *neg) Trim() Evaluatable {
+	// Eliminates a double negation
+	// Folds the negation of a numeric constant
+	return negate(n.left.Trim())
+}
+
+// Returns the negation of a trimmed expression, trimmed
+func negate(e Evaluatable) Evaluatable {
+	if n, ok := e.(*neg); ok {
+		return n.left
+	}
+	if cValue, ok := constantValue(e); ok && cValue == 0.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(e); ok {
+		return GetConstantValue(-value)
+	}
+	return NodeNeg(e)
+}
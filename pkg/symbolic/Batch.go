@@ -0,0 +1,175 @@
+package symbolic
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Number of points each instruction processes at once
+const batchChunk = 256
+
+// EvaluateBatch evaluates the expression at every point of the columns into out.
+// columns[j][i] is the value of vars[j] at point i, and every column has the length of out.
+// Each operator runs one loop over the points, and the results are bit identical to
+// evaluating point by point. Points out of the domain give NaN or infinities.
+func EvaluateBatch(expr Evaluatable, vars []*Variable, columns [][]float64, out []float64) error {
+	program, err := Compile(expr, vars)
+	if err != nil {
+		return err
+	}
+	return program.EvalBatch(columns, out)
+}
+
+// EvaluateBatchParallel works like EvaluateBatch splitting the points across workers goroutines.
+// A zero or negative number of workers uses GOMAXPROCS.
+func EvaluateBatchParallel(expr Evaluatable, vars []*Variable, columns [][]float64, out []float64, workers int) error {
+	program, err := Compile(expr, vars)
+	if err != nil {
+		return err
+	}
+	return program.EvalBatchParallel(columns, out, workers)
+}
+
+// EvalBatch runs the Program at every point of the columns into out, see EvaluateBatch
+func (p *Program) EvalBatch(columns [][]float64, out []float64) error {
+	if err := p.checkColumns(columns, out); err != nil {
+		return err
+	}
+	p.evalRange(columns, out, 0, len(out))
+	return nil
+}
+
+// EvalBatchParallel runs the Program at every point of the columns into out, see EvaluateBatchParallel
+func (p *Program) EvalBatchParallel(columns [][]float64, out []float64, workers int) error {
+	if err := p.checkColumns(columns, out); err != nil {
+		return err
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	size := (len(out) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(out); start += size {
+		end := start + size
+		if end > len(out) {
+			end = len(out)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			p.evalRange(columns, out, start, end)
+		}(start, end)
+	}
+	wg.Wait()
+	return nil
+}
+
+// Checks there is one column per input, each one with the length of out
+func (p *Program) checkColumns(columns [][]float64, out []float64) error {
+	if len(columns) != p.inputs {
+		return fmt.Errorf("%w: %d columns for %d variables", ErrDimension, len(columns), p.inputs)
+	}
+	for j, column := range columns {
+		if len(column) != len(out) {
+			return fmt.Errorf("%w: column %d has %d points, out has %d", ErrDimension, j, len(column), len(out))
+		}
+	}
+	return nil
+}
+
+// Runs the Program on the points from start to end, chunk by chunk.
+// Every slot of the stack is a slice holding the values of a chunk.
+func (p *Program) evalRange(columns [][]float64, out []float64, start, end int) {
+	stack := make([][]float64, len(p.stack))
+	for k := range stack {
+		stack[k] = make([]float64, batchChunk)
+	}
+	for lo := start; lo < end; lo += batchChunk {
+		hi := lo + batchChunk
+		if hi > end {
+			hi = end
+		}
+		n := hi - lo
+		sp := 0
+		for _, in := range p.code {
+			switch in.op {
+			case opConstant:
+				dst := stack[sp][:n]
+				value := p.constants[in.arg]
+				for i := range dst {
+					dst[i] = value
+				}
+				sp++
+			case opInput:
+				copy(stack[sp][:n], columns[in.arg][lo:hi])
+				sp++
+			case opAdd:
+				sp--
+				dst, src := stack[sp-1][:n], stack[sp][:n]
+				for i := range dst {
+					dst[i] += src[i]
+				}
+			case opSub:
+				sp--
+				dst, src := stack[sp-1][:n], stack[sp][:n]
+				for i := range dst {
+					dst[i] -= src[i]
+				}
+			case opMultiply:
+				sp--
+				dst, src := stack[sp-1][:n], stack[sp][:n]
+				for i := range dst {
+					dst[i] *= src[i]
+				}
+			case opDivide:
+				sp--
+				dst, src := stack[sp-1][:n], stack[sp][:n]
+				for i := range dst {
+					dst[i] /= src[i]
+				}
+			case opPow:
+				sp--
+				dst, src := stack[sp-1][:n], stack[sp][:n]
+				for i := range dst {
+					dst[i] = math.Pow(dst[i], src[i])
+				}
+			case opLn:
+				dst := stack[sp-1][:n]
+				for i := range dst {
+					dst[i] = math.Log(dst[i])
+				}
+			case opSin:
+				dst := stack[sp-1][:n]
+				for i := range dst {
+					dst[i] = math.Sin(dst[i])
+				}
+			case opCos:
+				dst := stack[sp-1][:n]
+				for i := range dst {
+					dst[i] = math.Cos(dst[i])
+				}
+			case opNeg:
+				dst := stack[sp-1][:n]
+				for i := range dst {
+					dst[i] = -dst[i]
+				}
+			case opApply1:
+				op := p.operators[in.arg]
+				dst := stack[sp-1][:n]
+				for i := range dst {
+					dst[i] = applyOrNaN(op, dst[i], 0.0)
+				}
+			case opApply2:
+				sp--
+				op := p.operators[in.arg]
+				dst, src := stack[sp-1][:n], stack[sp][:n]
+				for i := range dst {
+					dst[i] = applyOrNaN(op, dst[i], src[i])
+				}
+			}
+		}
+		copy(out[lo:hi], stack[0][:n])
+	}
+}
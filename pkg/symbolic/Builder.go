@@ -0,0 +1,218 @@
+package symbolic
+
+// A Builder interns expressions by structure, so that identical subtrees become
+// a single shared node and the trees it returns are directed acyclic graphs.
+// Variables are interned by name, the first one seen is kept.
+type Builder struct {
+	buckets     map[uint64][]Evaluatable      // Interned nodes by structural hash
+	hashes      map[Evaluatable]uint64        // Structural hash of every interned node
+	derivatives map[derivativeKey]Evaluatable // Interned derivative of an interned node
+	functionOf  map[derivativeKey]bool        // Whether an interned node depends on a variable
+}
+
+// An interned node and the name of the variable it is differentiated with respect to
+type derivativeKey struct {
+	node Evaluatable
+	name string
+}
+
+func CreateBuilder() *Builder {
+	return &Builder{
+		buckets:     make(map[uint64][]Evaluatable),
+		hashes:      make(map[Evaluatable]uint64),
+		derivatives: make(map[derivativeKey]Evaluatable),
+		functionOf:  make(map[derivativeKey]bool),
+	}
+}
+
+// Returns the number of distinct nodes interned so far
+func (b *Builder) Size() int {
+	return len(b.hashes)
+}
+
+// Intern returns the shared node structurally equal to e, interning its subtrees on the way
+func (b *Builder) Intern(e Evaluatable) Evaluatable {
+	if _, ok := b.hashes[e]; ok {
+		return e
+	}
+	op, ok := e.(operator)
+	if !ok {
+		h := hash(e, false)
+		for _, c := range b.buckets[h] {
+			if Equal(c, e) {
+				return c
+			}
+		}
+		return b.store(e, h)
+	}
+
+	left, right := op.operands()
+	left = b.Intern(left)
+	h := hashOperator(op.symbol(), b.hashes[left])
+	if right != nil {
+		right = b.Intern(right)
+		h = hashOperator(op.symbol(), b.hashes[left], b.hashes[right])
+	}
+	for _, c := range b.buckets[h] {
+		if sameOperator(c, op.symbol(), left, right) {
+			return c
+		}
+	}
+	if eLeft, eRight := op.operands(); eLeft != left || eRight != right {
+		e = op.rebuild(left, right)
+	}
+	return b.store(e, h)
+}
+
+// Adds e to the interned nodes under the hash h
+func (b *Builder) store(e Evaluatable, h uint64) Evaluatable {
+	b.buckets[h] = append(b.buckets[h], e)
+	b.hashes[e] = h
+	return e
+}
+
+// Returns true if c is the operator symbol applied to the given operands.
+// Operands are interned, so comparing their pointers is enough.
+func sameOperator(c Evaluatable, symbol string, left, right Evaluatable) bool {
+	op, ok := c.(operator)
+	if !ok || op.symbol() != symbol {
+		return false
+	}
+	cLeft, cRight := op.operands()
+	return cLeft == left && cRight == right
+}
+
+// Returns the interned derivative of e with respect to v.
+// The derivative of every interned node is computed once per variable and shared,
+// so repeated differentiation grows with the size of the DAG instead of the tree.
+func (b *Builder) Diff(e Evaluatable, v *Variable) Evaluatable {
+	return b.diff(b.Intern(e), v)
+}
+
+// Returns the memoized derivative of the interned node e
+func (b *Builder) diff(e Evaluatable, v *Variable) Evaluatable {
+	key := derivativeKey{e, v.name}
+	if d, ok := b.derivatives[key]; ok {
+		return d
+	}
+	var d Evaluatable
+	if op, ok := e.(operator); ok {
+		// Apply the rule of e alone, the operands answer with their memoized derivatives
+		left, right := op.operands()
+		d = b.resolve(op.rebuild(b.operand(left, v), b.operand(right, v)).Diff(v))
+	} else {
+		d = b.Intern(e.Diff(v))
+	}
+	b.derivatives[key] = d
+	return d
+}
+
+// Returns true if the interned node e is a function of v, memoized like diff
+func (b *Builder) isFunctionOf(e Evaluatable, v *Variable) bool {
+	key := derivativeKey{e, v.name}
+	if isFunc, ok := b.functionOf[key]; ok {
+		return isFunc
+	}
+	var isFunc bool
+	if op, ok := e.(operator); ok {
+		left, right := op.operands()
+		isFunc = b.isFunctionOf(left, v) || (right != nil && b.isFunctionOf(right, v))
+	} else {
+		isFunc = e.FunctionOf(v)
+	}
+	b.functionOf[key] = isFunc
+	return isFunc
+}
+
+// Wraps the interned operand e so that differentiating its parent does not walk it again.
+// Leaves are cheap to differentiate and are kept as they are.
+func (b *Builder) operand(e Evaluatable, v *Variable) Evaluatable {
+	if _, ok := e.(operator); !ok {
+		return e
+	}
+	return &builtOperand{e, b}
+}
+
+// Replaces the wrapped operands of e by their nodes and interns the result.
+// Interned subtrees are left untouched, so only the new nodes are visited.
+func (b *Builder) resolve(e Evaluatable) Evaluatable {
+	if o, ok := e.(*builtOperand); ok {
+		return o.Evaluatable
+	}
+	if _, ok := b.hashes[e]; ok {
+		return e
+	}
+	op, ok := e.(operator)
+	if !ok {
+		return b.Intern(e)
+	}
+	left, right := op.operands()
+	if right != nil {
+		right = b.resolve(right)
+	}
+	return b.Intern(op.rebuild(b.resolve(left), right))
+}
+
+// An interned operand seen by the rule of its parent in Builder.Diff.
+// It answers FunctionOf and Diff from the memos of the Builder.
+type builtOperand struct {
+	Evaluatable
+	b *Builder
+}
+
+func (o *builtOperand) FunctionOf(v *Variable) bool {
+	return o.b.isFunctionOf(o.Evaluatable, v)
+}
+
+func (o *builtOperand) Diff(v *Variable) Evaluatable {
+	return o.b.diff(o.Evaluatable, v)
+}
+
+// EvaluateShared evaluates the expressions with env in a single pass.
+// Nodes shared within or across the expressions are evaluated only once.
+func EvaluateShared(env Env, exprs ...Evaluatable) ([]float64, error) {
+	memo := make(map[Evaluatable]float64)
+	values := make([]float64, len(exprs))
+	for i, expr := range exprs {
+		value, err := evaluateMemo(expr, env, memo)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// Evaluates e with env, reading and filling the values of the nodes already seen in memo
+func evaluateMemo(e Evaluatable, env Env, memo map[Evaluatable]float64) (float64, error) {
+	if value, ok := memo[e]; ok {
+		return value, nil
+	}
+	op, ok := e.(operator)
+	if !ok {
+		value, err := e.EvaluateWith(env)
+		if err != nil {
+			return 0.0, err
+		}
+		memo[e] = value
+		return value, nil
+	}
+
+	left, right := op.operands()
+	lValue, err := evaluateMemo(left, env, memo)
+	if err != nil {
+		return 0.0, err
+	}
+	rValue := 0.0
+	if right != nil {
+		if rValue, err = evaluateMemo(right, env, memo); err != nil {
+			return 0.0, err
+		}
+	}
+	value, err := op.apply(lValue, rValue)
+	if err != nil {
+		return 0.0, &EvaluationError{Expr: op.String(), Err: err}
+	}
+	memo[e] = value
+	return value, nil
+}
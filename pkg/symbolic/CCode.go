@@ -0,0 +1,135 @@
+package symbolic
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// The syntax of C99, using <math.h>. Named constants are written as numbers since M_PI is not standard.
+var cLanguage = language{
+	functions: map[string]string{
+		"^":     "pow",
+		"ln":    "log",
+		"sin":   "sin",
+		"cos":   "cos",
+		"tan":   "tan",
+		"exp":   "exp",
+		"sqrt":  "sqrt",
+		"abs":   "fabs",
+		"sign":  "sign", // Defined by the generated source since math.h has none
+		"asin":  "asin",
+		"acos":  "acos",
+		"atan":  "atan",
+		"atan2": "atan2",
+		"sinh":  "sinh",
+		"cosh":  "cosh",
+		"tanh":  "tanh",
+		"asinh": "asinh",
+		"acosh": "acosh",
+		"atanh": "atanh",
+		"log10": "log10",
+		"log2":  "log2",
+	},
+	number: cNumber,
+	keywords: []string{
+		"auto", "break", "case", "char", "const", "continue", "default", "do", "double", "else",
+		"enum", "extern", "float", "for", "goto", "if", "inline", "int", "long", "register",
+		"restrict", "return", "short", "signed", "sizeof", "static", "struct", "switch", "typedef",
+		"union", "unsigned", "void", "volatile", "while", "_Bool", "_Complex", "_Imaginary", "out",
+	},
+}
+
+// CExpr writes the expression in C99, with its Variables by name
+func CExpr(e Evaluatable, opts *CodeOptions) (string, error) {
+	c := coder{lang: cLanguage.with(opts)}
+	return c.code(e)
+}
+
+// GenerateC returns a C99 source including <math.h> with the functions.
+// A single expression gives double Name(double x, double y), vectors and matrices
+// give void Name(double x, double y, double *out) filling out, matrices row by row.
+func GenerateC(opts *CodeOptions, funcs ...CodeFunction) (string, error) {
+	lang := cLanguage.with(opts)
+	var b strings.Builder
+	usesSign := false
+	for _, f := range funcs {
+		if !isIdentifier(f.Name) || lang.reserved()[f.Name] {
+			return "", fmt.Errorf("invalid function name %q", f.Name)
+		}
+		if err := f.validate(); err != nil {
+			return "", err
+		}
+		body, err := lang.body(f.Vars, f.Exprs)
+		if err != nil {
+			return "", err
+		}
+		usesSign = usesSign || body.calls["sign"]
+
+		params := make([]string, len(body.params))
+		for i, param := range body.params {
+			params[i] = "double " + param
+		}
+		multiple := len(f.Exprs) > 1 || f.Cols > 0
+		b.WriteString("\n")
+		switch {
+		case f.Cols > 0:
+			fmt.Fprintf(&b, "/* Fills out with the %dx%d matrix, row by row */\n", len(f.Exprs)/f.Cols, f.Cols)
+			params = append(params, "double *out")
+			fmt.Fprintf(&b, "void %s(%s)\n{\n", f.Name, strings.Join(params, ", "))
+		case multiple:
+			fmt.Fprintf(&b, "/* Fills out with the %d outputs */\n", len(f.Exprs))
+			params = append(params, "double *out")
+			fmt.Fprintf(&b, "void %s(%s)\n{\n", f.Name, strings.Join(params, ", "))
+		default:
+			if len(params) == 0 {
+				params = append(params, "void")
+			}
+			fmt.Fprintf(&b, "double %s(%s)\n{\n", f.Name, strings.Join(params, ", "))
+		}
+		for _, temp := range body.temporaries {
+			fmt.Fprintf(&b, "    const double %s = %s;\n", temp.name, temp.code)
+		}
+		for i, output := range body.outputs {
+			if multiple {
+				fmt.Fprintf(&b, "    out[%d] = %s;\n", i, output)
+			} else {
+				fmt.Fprintf(&b, "    return %s;\n", output)
+			}
+		}
+		b.WriteString("}\n")
+	}
+
+	header := "#include <math.h>\n"
+	if usesSign && lang.functions["sign"] == "sign" {
+		header += cSign
+	}
+	return header + b.String(), nil
+}
+
+// The sign function, defined by the sources using it
+const cSign = `
+static double sign(double x)
+{
+    if (x > 0.0) {
+        return 1.0;
+    } else if (x < 0.0) {
+        return -1.0;
+    }
+    return x;
+}
+`
+
+// Writes a double literal, using the macros of <math.h> for infinities and NaN
+func cNumber(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NAN"
+	case math.IsInf(v, 0):
+		if v > 0 {
+			return "INFINITY"
+		}
+		return "-INFINITY"
+	}
+	return decimalNumber(v)
+}
@@ -0,0 +1,187 @@
+package symbolic
+
+import (
+	"strconv"
+)
+
+// A Temporary holds a subexpression that is used more than once
+type Temporary struct {
+	Var  *Variable   // Variable standing for the subexpression in later expressions
+	Expr Evaluatable // Subexpression written in terms of the previous Temporaries
+}
+
+// A StraightLine program evaluates its Temporaries in order, then its Outputs
+type StraightLine struct {
+	Temporaries []Temporary
+	Outputs     []Evaluatable // Outputs written in terms of the Temporaries
+	Report      CSEReport
+}
+
+// How much work the common subexpression elimination saved
+type CSEReport struct {
+	TreeNodes int // Nodes visited when evaluating every output as a tree
+	Nodes     int // Nodes visited by the StraightLine program
+}
+
+// Returns the number of nodes the program does not need to evaluate
+func (r CSEReport) Saved() int {
+	return r.TreeNodes - r.Nodes
+}
+
+// CSE extracts the subtrees repeated within or across the expressions into Temporaries.
+// Temporaries are named t0, t1, ... unless those names are taken by a Variable of the expressions.
+func CSE(exprs ...Evaluatable) *StraightLine {
+	b := CreateBuilder()
+	outputs := make([]Evaluatable, len(exprs))
+	for i, expr := range exprs {
+		outputs[i] = b.Intern(expr)
+	}
+
+	// Count the references to every node of the DAG
+	refs := make(map[Evaluatable]int)
+	names := make(map[string]bool)
+	var count func(e Evaluatable)
+	count = func(e Evaluatable) {
+		refs[e]++
+		if refs[e] > 1 {
+			return
+		}
+		if v, ok := e.(*Variable); ok {
+			names[v.name] = true
+		}
+		if op, ok := e.(operator); ok {
+			left, right := op.operands()
+			count(left)
+			if right != nil {
+				count(right)
+			}
+		}
+	}
+	for _, output := range outputs {
+		count(output)
+	}
+
+	c := cse{
+		refs:     refs,
+		replaced: make(map[Evaluatable]Evaluatable),
+		prefix:   temporaryPrefix(names),
+		program:  &StraightLine{Outputs: make([]Evaluatable, len(outputs))},
+	}
+	for i, output := range outputs {
+		c.program.Outputs[i] = c.rewrite(output)
+	}
+
+	treeSizes := make(map[Evaluatable]int)
+	for _, expr := range exprs {
+		c.program.Report.TreeNodes += treeSize(expr, treeSizes)
+	}
+	for _, temp := range c.program.Temporaries {
+		c.program.Report.Nodes += treeSize(temp.Expr, treeSizes)
+	}
+	for _, output := range c.program.Outputs {
+		c.program.Report.Nodes += treeSize(output, treeSizes)
+	}
+	return c.program
+}
+
+// The state of the rewriting of a DAG into a StraightLine program
+type cse struct {
+	refs     map[Evaluatable]int
+	replaced map[Evaluatable]Evaluatable // Rewritten form of every node visited
+	prefix   string
+	program  *StraightLine
+}
+
+// Rewrites e in terms of Temporaries, adding one for every operator referenced more than once.
+// Constant subtrees such as -2 are cheap to repeat and stay inline.
+func (c *cse) rewrite(e Evaluatable) Evaluatable {
+	if r, ok := c.replaced[e]; ok {
+		return r
+	}
+	op, ok := e.(operator)
+	if !ok {
+		return e
+	}
+	left, right := op.operands()
+	left = c.rewrite(left)
+	if right != nil {
+		right = c.rewrite(right)
+	}
+	r := op.rebuild(left, right)
+	if c.refs[e] > 1 && !e.IsConstant() {
+		v := CreateVariable(c.prefix + strconv.Itoa(len(c.program.Temporaries)))
+		c.program.Temporaries = append(c.program.Temporaries, Temporary{Var: v, Expr: r})
+		r = v
+	}
+	c.replaced[e] = r
+	return r
+}
+
+// Evaluates the Temporaries and the Outputs with the given Env
+func (p *StraightLine) Evaluate(env Env) ([]float64, error) {
+	local := make(Env, len(env)+len(p.Temporaries))
+	for name, value := range env {
+		local[name] = value
+	}
+	for _, temp := range p.Temporaries {
+		value, err := temp.Expr.EvaluateWith(local)
+		if err != nil {
+			return nil, err
+		}
+		local[temp.Var.name] = value
+	}
+	values := make([]float64, len(p.Outputs))
+	for i, output := range p.Outputs {
+		value, err := output.EvaluateWith(local)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// Returns a prefix for the Temporaries that no Variable name starts with followed by digits
+func temporaryPrefix(names map[string]bool) string {
+	prefix := "t"
+	for {
+		taken := false
+		for name := range names {
+			if len(name) > len(prefix) && name[:len(prefix)] == prefix && isNumber(name[len(prefix):]) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return prefix
+		}
+		prefix = "_" + prefix
+	}
+}
+
+// Returns true if s is made of digits only
+func isNumber(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Returns the number of nodes of e seen as a tree, memoized by node
+func treeSize(e Evaluatable, sizes map[Evaluatable]int) int {
+	if size, ok := sizes[e]; ok {
+		return size
+	}
+	size := 1
+	if op, ok := e.(operator); ok {
+		left, right := op.operands()
+		size += treeSize(left, sizes)
+		if right != nil {
+			size += treeSize(right, sizes)
+		}
+	}
+	sizes[e] = size
+	return size
+}
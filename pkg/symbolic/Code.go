@@ -0,0 +1,280 @@
+package symbolic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The syntax of a target language of the code generators
+type language struct {
+	functions map[string]string      // Function names by operator symbol, "^" included unless power is set
+	power     string                 // Infix power operator, like ** in Python, empty to call functions["^"]
+	constants map[string]string      // Names of the named Constants, others are written as numbers
+	number    func(v float64) string // Writes a numeric literal
+	keywords  []string               // Names the generated code can not give to a parameter
+}
+
+// CodeOptions configure the C and Python generators
+type CodeOptions struct {
+	Functions map[string]string // Function names by operator symbol replacing the defaults, like "sin": "fast_sin"
+}
+
+// A CodeFunction describes a function to generate.
+// A single expression gives a function returning a number, several give a vector,
+// and a positive Cols makes Exprs a matrix stored row by row.
+type CodeFunction struct {
+	Name  string
+	Vars  []*Variable // Parameters, in order
+	Exprs []Evaluatable
+	Cols  int
+}
+
+// MatrixFunction returns the CodeFunction computing the matrix with the given rows
+func MatrixFunction(name string, vars []*Variable, rows [][]Evaluatable) CodeFunction {
+	f := CodeFunction{Name: name, Vars: vars}
+	for _, row := range rows {
+		f.Exprs = append(f.Exprs, row...)
+	}
+	if len(rows) > 0 {
+		f.Cols = len(rows[0])
+	}
+	return f
+}
+
+// Checks the function has something to compute and a matrix has full rows
+func (f CodeFunction) validate() error {
+	if len(f.Exprs) == 0 {
+		return fmt.Errorf("function %s has no expressions", f.Name)
+	}
+	if f.Cols > 0 && len(f.Exprs)%f.Cols != 0 {
+		return fmt.Errorf("%w: %d entries in rows of %d", ErrDimension, len(f.Exprs), f.Cols)
+	}
+	return nil
+}
+
+// Returns the language with the function names given by the options
+func (l *language) with(opts *CodeOptions) *language {
+	if opts == nil || len(opts.Functions) == 0 {
+		return l
+	}
+	custom := *l
+	custom.functions = make(map[string]string, len(l.functions))
+	for symbol, name := range l.functions {
+		custom.functions[symbol] = name
+	}
+	for symbol, name := range opts.Functions {
+		custom.functions[symbol] = name
+	}
+	return &custom
+}
+
+// Returns the keywords along with the packages or functions the generated code refers to
+func (l *language) reserved() map[string]bool {
+	reserved := make(map[string]bool)
+	for _, keyword := range l.keywords {
+		reserved[keyword] = true
+	}
+	for _, name := range l.functions {
+		if i := strings.IndexByte(name, '.'); i >= 0 {
+			name = name[:i]
+		}
+		reserved[name] = true
+	}
+	return reserved
+}
+
+// The code of a function: parameters, temporaries holding repeated subexpressions, then the outputs
+type codeBody struct {
+	params      []string
+	temporaries []codeAssignment
+	outputs     []string
+	calls       map[string]bool // Symbols of the operators written as function calls
+}
+
+type codeAssignment struct {
+	name string
+	code string
+}
+
+// Writes the expressions in terms of the parameters, failing on names the language can not use
+func (l *language) body(vars []*Variable, exprs []Evaluatable) (*codeBody, error) {
+	reserved := l.reserved()
+	names := make(map[string]string, len(vars))
+	b := &codeBody{}
+	for _, v := range vars {
+		if !isIdentifier(v.name) || reserved[v.name] {
+			return nil, fmt.Errorf("invalid parameter name %q", v.name)
+		}
+		if _, ok := names[v.name]; ok {
+			return nil, fmt.Errorf("duplicate parameter %q", v.name)
+		}
+		names[v.name] = v.name
+		reserved[v.name] = true
+		b.params = append(b.params, v.name)
+	}
+
+	// The names of the temporaries must not collide with the parameters either
+	program := CSE(exprs...)
+	prefix := temporaryPrefix(reserved)
+	for i, temp := range program.Temporaries {
+		names[temp.Var.name] = prefix + strconv.Itoa(i)
+	}
+	c := coder{lang: l, names: names, calls: make(map[string]bool)}
+	for _, temp := range program.Temporaries {
+		text, err := c.code(temp.Expr)
+		if err != nil {
+			return nil, err
+		}
+		b.temporaries = append(b.temporaries, codeAssignment{names[temp.Var.name], text})
+	}
+	for _, output := range program.Outputs {
+		text, err := c.code(output)
+		if err != nil {
+			return nil, err
+		}
+		b.outputs = append(b.outputs, text)
+	}
+	b.calls = c.calls
+	return b, nil
+}
+
+// Returns true if name is made of ASCII letters, digits and underscores, not starting with a digit
+func isIdentifier(name string) bool {
+	if name == "" || !isLetter(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isIdentChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Writes expressions in a language. Variables are written with their entry of names,
+// a Variable without one is unbound. With no names at all Variables keep their own.
+type coder struct {
+	lang  *language
+	names map[string]string
+	calls map[string]bool // If not nil, records the symbols written as function calls
+}
+
+// Returns the code of e
+func (c *coder) code(e Evaluatable) (string, error) {
+	text, _, err := c.expr(e)
+	return text, err
+}
+
+// Returns the code of e along with its precedence
+func (c *coder) expr(e Evaluatable) (string, int, error) {
+	switch n := e.(type) {
+	case *Variable:
+		if c.names == nil {
+			return n.name, precedenceAtom, nil
+		}
+		name, ok := c.names[n.name]
+		if !ok {
+			return "", 0, &EvaluationError{Expr: n.name, Err: ErrUnboundVariable}
+		}
+		return name, precedenceAtom, nil
+	case *Constant:
+		if name, ok := c.lang.constants[n.name]; ok {
+			return name, precedenceAtom, nil
+		}
+		text := c.lang.number(n.value)
+		if text[0] == '-' {
+			return text, precedenceNegation, nil
+		}
+		return text, precedenceAtom, nil
+	case *add:
+		return c.infix(n.left, " + ", n.right, precedenceSum, precedenceProduct, precedenceSum)
+	case *sub:
+		return c.infix(n.left, " - ", n.right, precedenceSum, precedenceProduct, precedenceSum)
+	case *multiply:
+		return c.infix(n.left, " * ", n.right, precedenceProduct, precedencePower, precedenceProduct)
+	case *divide:
+		return c.infix(n.left, " / ", n.right, precedenceProduct, precedencePower, precedenceProduct)
+	case *pow:
+		if c.lang.power != "" {
+			return c.infix(n.left, " "+c.lang.power+" ", n.right, precedenceAtom, precedencePower, precedencePower)
+		}
+		return c.call(n)
+	case *neg:
+		operand, err := c.operand(n.left, precedencePower)
+		return "-" + operand, precedenceNegation, err
+	case *log:
+		return c.logarithm(n)
+	case operator:
+		return c.call(n)
+	default:
+		return "", 0, fmt.Errorf("%w: %s", ErrUnsupported, e)
+	}
+}
+
+// Writes a binary operator whose operands need at least the given precedences
+func (c *coder) infix(left Evaluatable, op string, right Evaluatable, lp, rp, precedence int) (string, int, error) {
+	l, err := c.operand(left, lp)
+	if err != nil {
+		return "", 0, err
+	}
+	r, err := c.operand(right, rp)
+	if err != nil {
+		return "", 0, err
+	}
+	return l + op + r, precedence, nil
+}
+
+// Returns the code of e wrapped in parentheses if it binds weaker than precedence
+func (c *coder) operand(e Evaluatable, precedence int) (string, error) {
+	text, p, err := c.expr(e)
+	if p < precedence {
+		text = "(" + text + ")"
+	}
+	return text, err
+}
+
+// Writes an operator as a call to the function of its symbol
+func (c *coder) call(op operator) (string, int, error) {
+	left, right := op.operands()
+	if right == nil {
+		return c.function(op.symbol(), left)
+	}
+	return c.function(op.symbol(), left, right)
+}
+
+// Writes a call to the function of the language named by symbol
+func (c *coder) function(symbol string, operands ...Evaluatable) (string, int, error) {
+	name, ok := c.lang.functions[symbol]
+	if !ok {
+		return "", 0, fmt.Errorf("%w %q", ErrUnsupported, symbol)
+	}
+	if c.calls != nil {
+		c.calls[symbol] = true
+	}
+	args := make([]string, len(operands))
+	for i, operand := range operands {
+		text, err := c.code(operand)
+		if err != nil {
+			return "", 0, err
+		}
+		args[i] = text
+	}
+	return name + "(" + strings.Join(args, ", ") + ")", precedenceAtom, nil
+}
+
+// Writes log_b(u) with the log10 or log2 function of the language for those bases, ln(u) / ln(b) otherwise
+func (c *coder) logarithm(l *log) (string, int, error) {
+	if value, ok := literalValue(l.left); ok {
+		symbol := ""
+		if value == 10.0 {
+			symbol = "log10"
+		} else if value == 2.0 {
+			symbol = "log2"
+		}
+		if _, ok := c.lang.functions[symbol]; ok {
+			return c.function(symbol, l.right)
+		}
+	}
+	return c.expr(NodeDivide(NodeLn(l.right), NodeLn(l.left)))
+}
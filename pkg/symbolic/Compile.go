@@ -0,0 +1,202 @@
+package symbolic
+
+import (
+	"fmt"
+	"math"
+)
+
+// Operation codes of the stack machine
+type opcode uint8
+
+const (
+	opConstant opcode = iota // Push constants[arg]
+	opInput                  // Push inputs[arg]
+	opAdd
+	opSub
+	opMultiply
+	opDivide
+	opPow
+	opLn
+	opSin
+	opCos
+	opNeg
+	opApply1 // Apply operators[arg] to the top of the stack
+	opApply2 // Apply operators[arg] to the two values on top of the stack
+)
+
+// Opcodes of the operators the machine runs natively, by symbol
+var opcodes = map[string]opcode{
+	"+":   opAdd,
+	"-":   opSub,
+	"*":   opMultiply,
+	"/":   opDivide,
+	"^":   opPow,
+	"ln":  opLn,
+	"sin": opSin,
+	"cos": opCos,
+	"neg": opNeg,
+}
+
+type instruction struct {
+	op  opcode
+	arg int32
+}
+
+// A Program is an expression compiled to the bytecode of a stack machine.
+// A Program reuses its stack, use Clone to evaluate it from several goroutines.
+type Program struct {
+	inputs    int
+	code      []instruction
+	constants []float64
+	operators []operator // Operators without an opcode of their own
+	stack     []float64
+}
+
+// Compile translates the expression to a Program whose inputs are the given Variables, in order.
+// Fails if the expression depends on a Variable that is not part of vars.
+func Compile(e Evaluatable, vars []*Variable) (*Program, error) {
+	c := compiler{
+		program:   &Program{inputs: len(vars)},
+		inputs:    make(map[string]int32, len(vars)),
+		constants: make(map[uint64]int32),
+	}
+	for i, v := range vars {
+		c.inputs[v.name] = int32(i)
+	}
+	if err := c.compile(e); err != nil {
+		return nil, err
+	}
+	c.program.stack = make([]float64, c.maxDepth)
+	return c.program, nil
+}
+
+// The state of the translation of an expression into a Program
+type compiler struct {
+	program   *Program
+	inputs    map[string]int32
+	constants map[uint64]int32
+	depth     int
+	maxDepth  int
+}
+
+// Emits the instructions that leave the value of e on top of the stack
+func (c *compiler) compile(e Evaluatable) error {
+	switch n := e.(type) {
+	case *Variable:
+		index, ok := c.inputs[n.name]
+		if !ok {
+			return &EvaluationError{Expr: n.name, Err: ErrUnboundVariable}
+		}
+		c.emit(opInput, index, 1)
+	case *Constant:
+		// Keyed by bits to tell -0 from 0
+		bits := math.Float64bits(n.value)
+		index, ok := c.constants[bits]
+		if !ok {
+			index = int32(len(c.program.constants))
+			c.program.constants = append(c.program.constants, n.value)
+			c.constants[bits] = index
+		}
+		c.emit(opConstant, index, 1)
+	case operator:
+		left, right := n.operands()
+		if err := c.compile(left); err != nil {
+			return err
+		}
+		if right != nil {
+			if err := c.compile(right); err != nil {
+				return err
+			}
+		}
+		// Binary operators pop two values and push one
+		delta := 0
+		if right != nil {
+			delta = -1
+		}
+		if op, ok := opcodes[n.symbol()]; ok {
+			c.emit(op, 0, delta)
+		} else {
+			c.program.operators = append(c.program.operators, n)
+			index := int32(len(c.program.operators) - 1)
+			if right == nil {
+				c.emit(opApply1, index, delta)
+			} else {
+				c.emit(opApply2, index, delta)
+			}
+		}
+	default:
+		return fmt.Errorf("can not compile %s: unknown node", e)
+	}
+	return nil
+}
+
+// Appends an instruction that changes the depth of the stack by delta
+func (c *compiler) emit(op opcode, arg int32, delta int) {
+	c.program.code = append(c.program.code, instruction{op, arg})
+	c.depth += delta
+	if c.depth > c.maxDepth {
+		c.maxDepth = c.depth
+	}
+}
+
+// Returns a copy of the Program with a stack of its own
+func (p *Program) Clone() *Program {
+	clone := *p
+	clone.stack = make([]float64, len(p.stack))
+	return &clone
+}
+
+// Eval runs the Program with the inputs in the order given to Compile, allocating nothing.
+// Domain errors give NaN or infinities like the math package instead of an error.
+func (p *Program) Eval(inputs []float64) float64 {
+	stack := p.stack
+	sp := 0
+	for _, in := range p.code {
+		switch in.op {
+		case opConstant:
+			stack[sp] = p.constants[in.arg]
+			sp++
+		case opInput:
+			stack[sp] = inputs[in.arg]
+			sp++
+		case opAdd:
+			sp--
+			stack[sp-1] += stack[sp]
+		case opSub:
+			sp--
+			stack[sp-1] -= stack[sp]
+		case opMultiply:
+			sp--
+			stack[sp-1] *= stack[sp]
+		case opDivide:
+			sp--
+			stack[sp-1] /= stack[sp]
+		case opPow:
+			sp--
+			stack[sp-1] = math.Pow(stack[sp-1], stack[sp])
+		case opLn:
+			stack[sp-1] = math.Log(stack[sp-1])
+		case opSin:
+			stack[sp-1] = math.Sin(stack[sp-1])
+		case opCos:
+			stack[sp-1] = math.Cos(stack[sp-1])
+		case opNeg:
+			stack[sp-1] = -stack[sp-1]
+		case opApply1:
+			stack[sp-1] = applyOrNaN(p.operators[in.arg], stack[sp-1], 0.0)
+		case opApply2:
+			sp--
+			stack[sp-1] = applyOrNaN(p.operators[in.arg], stack[sp-1], stack[sp])
+		}
+	}
+	return stack[0]
+}
+
+// Applies op to the values, NaN if they are out of its domain
+func applyOrNaN(op operator, left, right float64) float64 {
+	value, err := op.apply(left, right)
+	if err != nil {
+		return math.NaN()
+	}
+	return value
+}
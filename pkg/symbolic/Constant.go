@@ -25,24 +25,30 @@ var constantPool = map[string]Constant{
 
 // Gets a constant from the given input string. Only supports the already defined const strings, otherwise panic
 func GetConstant(c string) *Constant {
+	rConst, err := LookupConstant(c)
+	if err != nil {
+		panic(err)
+	}
+	return rConst
+}
+
+// Gets a constant from the given input string, ErrUnknownConstant if it is not defined
+func LookupConstant(c string) (*Constant, error) {
 	rConst, ok := constantPool[c]
-	if ok {
-		return &rConst
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownConstant, c)
 	}
-	panic("Unknown constant")
+	return &rConst, nil
 }
 
-// Get a constant from the given value
-func GetConstantValue(v float64) (rCnt *Constant) {
+// Get a constant from the given value. Only the predefined constants are shared, any other value
+// gets a fresh Constant so that the pool is never written and stays safe for concurrent use
+func GetConstantValue(v float64) *Constant {
 	name := fmt.Sprintf("%g", v) // Creates a name for this constant v
 	if cnt, ok := constantPool[name]; ok {
-		rCnt = &cnt
-	} else {
-		addCnt := Constant{name: name, value: v}
-		constantPool[name] = addCnt
-		rCnt = &addCnt
+		return &cnt
 	}
-	return
+	return &Constant{name: name, value: v}
 }
 
 // A Constant value float64
@@ -59,6 +65,15 @@ func (c *Constant) Evaluate() float64 {
 	return c.value
 }
 
+func (c *Constant) EvaluateErr() (float64, error) {
+	return c.value, nil
+}
+
+// Constants do not depend on the environment
+func (c *Constant) EvaluateWith(env Env) (float64, error) {
+	return c.value, nil
+}
+
 // Automatically returns false if got to constant leaf node
 func (c *Constant) FunctionOf(v *Variable) bool {
 	return false
@@ -81,3 +96,45 @@ func (c *Constant) String() string {
 func (c *Constant) Trim() Evaluatable {
 	return c
 }
+
+// Returns the value of e and true if e is a numeric Constant, named constants like pi excluded
+func literalValue(e Evaluatable) (float64, bool) {
+	if c, ok := e.(*Constant); ok && c.name == fmt.Sprintf("%g", c.value) {
+		return c.value, true
+	}
+	return 0.0, false
+}
+
+// Returns the values of both operands and true if both are numeric Constants
+func literalValues(left, right Evaluatable) (float64, float64, bool) {
+	lValue, lOk := literalValue(left)
+	rValue, rOk := literalValue(right)
+	return lValue, rValue, lOk && rOk
+}
+
+// Returns the value of e and true if e is constant and evaluates without errors
+func constantValue(e Evaluatable) (float64, bool) {
+	if !e.IsConstant() {
+		return 0.0, false
+	}
+	value, err := e.EvaluateErr()
+	return value, err == nil
+}
+
+// Returns the error of the first constant subtree of e that fails to evaluate, nil if there is none.
+// Trim panics on such subtrees, like 1 / 0 or 0 ^ 0, so they are checked before trimming.
+func constantError(e Evaluatable) error {
+	if e.IsConstant() {
+		_, err := e.EvaluateErr()
+		return err
+	}
+	op, ok := e.(operator)
+	if !ok {
+		return nil
+	}
+	left, right := op.operands()
+	if err := constantError(left); err != nil || right == nil {
+		return err
+	}
+	return constantError(right)
+}
@@ -0,0 +1,72 @@
+package symbolic
+
+import (
+	"sort"
+	"strings"
+)
+
+// Derivatives computes the partial derivatives of an expression, trimming after every step.
+// Every derivative is cached, and since mixed partials commute f_xy and f_yx are the same one.
+type Derivatives struct {
+	expr  Evaluatable
+	cache map[string]Evaluatable // By the sorted names of the variables
+}
+
+// Creates the Derivatives of the given expression
+func CreateDerivatives(expr Evaluatable) *Derivatives {
+	return &Derivatives{expr: expr, cache: make(map[string]Evaluatable)}
+}
+
+// Returns the expression the Derivatives are computed from
+func (d *Derivatives) Expr() Evaluatable {
+	return d.expr
+}
+
+// Partial returns the derivative of the expression along every given Variable in turn,
+// the expression itself if there are none. The order of the Variables does not matter.
+func (d *Derivatives) Partial(vars ...*Variable) Evaluatable {
+	sorted := make([]*Variable, len(vars))
+	copy(sorted, vars)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+	return d.partial(sorted)
+}
+
+// Differentiates the cached derivative along all the sorted Variables but the last one
+func (d *Derivatives) partial(sorted []*Variable) Evaluatable {
+	if len(sorted) == 0 {
+		return d.expr
+	}
+	names := make([]string, len(sorted))
+	for i, v := range sorted {
+		names[i] = v.name
+	}
+	key := strings.Join(names, "\x00")
+	if derivative, ok := d.cache[key]; ok {
+		return derivative
+	}
+	last := sorted[len(sorted)-1]
+	derivative := d.partial(sorted[:len(sorted)-1]).Diff(last).Trim()
+	d.cache[key] = derivative
+	return derivative
+}
+
+// DiffN returns the n-th derivative of the expression along v, trimming after every step.
+// Panics if n is negative.
+func DiffN(expr Evaluatable, v *Variable, n int) Evaluatable {
+	if n < 0 {
+		panic("DiffN: negative order")
+	}
+	vars := make([]*Variable, n)
+	for i := range vars {
+		vars[i] = v
+	}
+	return CreateDerivatives(expr).Partial(vars...)
+}
+
+// DiffMulti returns the mixed partial derivative of the expression along every given Variable,
+// so DiffMulti(f, []*Variable{x, x, y}) is f_xxy
+func DiffMulti(expr Evaluatable, vars []*Variable) Evaluatable {
+	return CreateDerivatives(expr).Partial(vars...)
+}
@@ -0,0 +1,123 @@
+package symbolic
+
+// Parameters of the 64 bit FNV-1a hash
+const (
+	hashOffset uint64 = 14695981039346656037
+	hashPrime  uint64 = 1099511628211
+)
+
+// Equal returns true if both expressions have the same structure: the same node kinds
+// with equal operands in the same order, and leaves with the same name.
+// Variables are told apart from Constants of the same name.
+func Equal(a, b Evaluatable) bool {
+	return equal(a, b, false)
+}
+
+// EqualCommutative works like Equal but also accepts swapped operands of + and *,
+// so x + y equals y + x. Operands are not regrouped: (x + y) + z differs from x + (y + z).
+func EqualCommutative(a, b Evaluatable) bool {
+	return equal(a, b, true)
+}
+
+func equal(a, b Evaluatable, commutative bool) bool {
+	if a == b {
+		return true
+	}
+	switch an := a.(type) {
+	case *Variable:
+		bn, ok := b.(*Variable)
+		return ok && an.name == bn.name
+	case *Constant:
+		bn, ok := b.(*Constant)
+		return ok && an.name == bn.name
+	case operator:
+		bn, ok := b.(operator)
+		if !ok || an.symbol() != bn.symbol() {
+			return false
+		}
+		aLeft, aRight := an.operands()
+		bLeft, bRight := bn.operands()
+		if equal(aLeft, bLeft, commutative) && equalOperand(aRight, bRight, commutative) {
+			return true
+		}
+		return commutative && isCommutative(an) &&
+			equal(aLeft, bRight, commutative) && equal(aRight, bLeft, commutative)
+	default:
+		return false
+	}
+}
+
+// Compares two right operands that are nil for functions of a single argument
+func equalOperand(a, b Evaluatable, commutative bool) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return equal(a, b, commutative)
+}
+
+// Returns true if the operands of op can be swapped
+func isCommutative(op operator) bool {
+	symbol := op.symbol()
+	return symbol == "+" || symbol == "*"
+}
+
+// Hash returns a 64 bit structural hash, stable across runs.
+// Expressions that are Equal have the same Hash.
+func Hash(e Evaluatable) uint64 {
+	return hash(e, false)
+}
+
+// HashCommutative returns a structural hash consistent with EqualCommutative
+func HashCommutative(e Evaluatable) uint64 {
+	return hash(e, true)
+}
+
+func hash(e Evaluatable, commutative bool) uint64 {
+	switch n := e.(type) {
+	case *Variable:
+		return hashString(hashString(hashOffset, "var:"), n.name)
+	case *Constant:
+		return hashString(hashString(hashOffset, "const:"), n.name)
+	case operator:
+		left, right := n.operands()
+		lHash := hash(left, commutative)
+		if right == nil {
+			return hashOperator(n.symbol(), lHash)
+		}
+		rHash := hash(right, commutative)
+		if commutative && isCommutative(n) && rHash < lHash {
+			lHash, rHash = rHash, lHash
+		}
+		return hashOperator(n.symbol(), lHash, rHash)
+	default:
+		return hashString(hashString(hashOffset, "expr:"), e.String())
+	}
+}
+
+// Returns the hash of an operator given the hashes of its operands
+func hashOperator(symbol string, operands ...uint64) uint64 {
+	h := hashString(hashString(hashOffset, "op:"), symbol)
+	for _, operand := range operands {
+		h = hashUint(h, operand)
+	}
+	return h
+}
+
+// Mixes the bytes of s into the hash h
+func hashString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= hashPrime
+	}
+	return h
+}
+
+// Mixes the bytes of v into the hash h
+func hashUint(h, v uint64) uint64 {
+	for i := 0; i < 8; i++ {
+		h ^= v & 0xff
+		h *= hashPrime
+		v >>= 8
+	}
+	return h
+}
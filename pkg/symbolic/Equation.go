@@ -0,0 +1,139 @@
+package symbolic
+
+import (
+	"strings"
+)
+
+// An Equation lhs = rhs, stored as the residual lhs - rhs
+type Equation struct {
+	lhs      Evaluatable
+	rhs      Evaluatable
+	residual Evaluatable
+}
+
+// Returns an Equation given the left and right hand sides: lhs = rhs.
+// Panics if a constant part of either side can not be evaluated, like 1 / 0
+func CreateEquation(lhs, rhs Evaluatable) *Equation {
+	eq, err := createEquation(lhs, rhs)
+	if err != nil {
+		panic(err)
+	}
+	return eq
+}
+
+// Returns an Equation lhs = rhs, or the error of a constant part that can not be evaluated
+func createEquation(lhs, rhs Evaluatable) (*Equation, error) {
+	residual := NodeSub(lhs, rhs)
+	if err := constantError(residual); err != nil {
+		return nil, err
+	}
+	return &Equation{lhs: lhs, rhs: rhs, residual: residual.Trim()}, nil
+}
+
+func (e *Equation) Lhs() Evaluatable {
+	return e.lhs
+}
+
+func (e *Equation) Rhs() Evaluatable {
+	return e.rhs
+}
+
+// Returns the trimmed lhs - rhs, which is zero when the Equation holds
+func (e *Equation) Residual() Evaluatable {
+	return e.residual
+}
+
+// Returns true if the residual of the Equation is function of v
+func (e *Equation) FunctionOf(v *Variable) bool {
+	return e.residual.FunctionOf(v)
+}
+
+func (e *Equation) String() string {
+	return e.lhs.String() + " = " + e.rhs.String()
+}
+
+// An EquationSystem owns a pool of unknown Variables and the Equations relating them.
+// Variables that are not part of the pool are treated as parameters.
+type EquationSystem struct {
+	pool      map[string]*Variable
+	variables []*Variable
+	equations []*Equation
+}
+
+func CreateEquationSystem() *EquationSystem {
+	return &EquationSystem{pool: make(map[string]*Variable)}
+}
+
+// Gets the unknown with the given name, creating it in the pool when missing
+func (s *EquationSystem) GetVariable(name string) *Variable {
+	if v, ok := s.pool[name]; ok {
+		return v
+	}
+	v := CreateVariable(name)
+	s.pool[name] = v
+	s.variables = append(s.variables, v)
+	return v
+}
+
+// Returns the unknowns in the order they were added to the pool
+func (s *EquationSystem) Variables() []*Variable {
+	return s.variables
+}
+
+// Returns the Equations in the order they were added
+func (s *EquationSystem) Equations() []*Equation {
+	return s.equations
+}
+
+// Returns the residual of every Equation, in order
+func (s *EquationSystem) Residuals() []Evaluatable {
+	residuals := make([]Evaluatable, len(s.equations))
+	for i, eq := range s.equations {
+		residuals[i] = eq.residual
+	}
+	return residuals
+}
+
+func (s *EquationSystem) AddEquation(eq *Equation) {
+	s.equations = append(s.equations, eq)
+}
+
+// Parses an equation such as "x ^ 2 + y = 1" and adds it to the system.
+// Names that are not constants or functions become unknowns of the pool.
+func (s *EquationSystem) ParseEquation(input string) (*Equation, error) {
+	split := strings.IndexByte(input, '=')
+	if split < 0 {
+		return nil, &ParseError{Offset: len(input), Msg: "expected \"=\", got end of input"}
+	}
+	if extra := strings.IndexByte(input[split+1:], '='); extra >= 0 {
+		return nil, &ParseError{Offset: split + 1 + extra, Token: "=", Msg: "unexpected token"}
+	}
+
+	// Parse on a copy of the pool so that a failure leaves the system untouched
+	pool := make(map[string]*Variable, len(s.pool))
+	for name, v := range s.pool {
+		pool[name] = v
+	}
+	lhs, lhsCreated, err := parseInto(input[:split], pool)
+	if err != nil {
+		return nil, err
+	}
+	rhs, rhsCreated, err := parseInto(input[split+1:], pool)
+	if err != nil {
+		if perr, ok := err.(*ParseError); ok {
+			perr.Offset += split + 1
+		}
+		return nil, err
+	}
+	eq, err := createEquation(lhs, rhs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range append(lhsCreated, rhsCreated...) {
+		s.pool[v.name] = v
+		s.variables = append(s.variables, v)
+	}
+	s.AddEquation(eq)
+	return eq, nil
+}
@@ -0,0 +1,51 @@
+package symbolic
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Returned when a denominator evaluates to zero
+var ErrDivisionByZero = errors.New("division by zero")
+
+// Returned when a constant name is not part of the constant pool
+var ErrUnknownConstant = errors.New("unknown constant")
+
+// Returned when a variable has no value in the evaluation environment
+var ErrUnboundVariable = errors.New("unbound variable")
+
+// Returned when slices do not have the expected lengths
+var ErrDimension = errors.New("dimension mismatch")
+
+// Returned when a system does not have as many equations as unknowns
+var ErrNotSquare = errors.New("system is not square")
+
+// Returned when a matrix can not be factorized
+var ErrSingularMatrix = errors.New("singular matrix")
+
+// Returned when a code generator has no translation for an operator
+var ErrUnsupported = errors.New("unsupported operator")
+
+// An ErrDomain is returned when a function is evaluated outside of its domain
+type ErrDomain struct {
+	Func string  // Name of the function
+	Arg  float64 // Offending argument
+}
+
+func (e *ErrDomain) Error() string {
+	return fmt.Sprintf("%s is not defined for %g", e.Func, e.Arg)
+}
+
+// An EvaluationError tells which sub-expression failed to evaluate and why
+type EvaluationError struct {
+	Expr string // String of the failing sub-expression
+	Err  error
+}
+
+func (e *EvaluationError) Error() string {
+	return "evaluating " + e.Expr + ": " + e.Err.Error()
+}
+
+func (e *EvaluationError) Unwrap() error {
+	return e.Err
+}
@@ -4,12 +4,49 @@ import (
 	"fmt"
 )
 
-// A Evaluatable interface for nodes in a binary expression tree
+// A Evaluatable interface for nodes in a binary expression tree.
+// String returns the fully parenthesized debug form, see Format for a readable one.
 type Evaluatable interface {
 	fmt.Stringer
 	Evaluate() float64
+	EvaluateErr() (float64, error)
+	EvaluateWith(env Env) (float64, error)
 	FunctionOf(v *Variable) bool
 	IsConstant() bool
 	Diff(v *Variable) Evaluatable
 	Trim() Evaluatable
 }
+
+// An Env binds variable names to the values used by EvaluateWith
+type Env map[string]float64
+
+func evaluateErr(op operator) (float64, error) {
+	return evaluateOperands(op, Evaluatable.EvaluateErr)
+}
+
+func evaluateWith(op operator, env Env) (float64, error) {
+	return evaluateOperands(op, func(e Evaluatable) (float64, error) {
+		return e.EvaluateWith(env)
+	})
+}
+
+// Evaluates the operands of op with eval and applies op to their values.
+// Errors are wrapped in an EvaluationError naming the failing sub-expression.
+func evaluateOperands(op operator, eval func(Evaluatable) (float64, error)) (float64, error) {
+	left, right := op.operands()
+	lValue, err := eval(left)
+	if err != nil {
+		return 0.0, err
+	}
+	rValue := 0.0
+	if right != nil {
+		if rValue, err = eval(right); err != nil {
+			return 0.0, err
+		}
+	}
+	value, err := op.apply(lValue, rValue)
+	if err != nil {
+		return 0.0, &EvaluationError{Expr: op.String(), Err: err}
+	}
+	return value, nil
+}
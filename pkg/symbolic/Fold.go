@@ -0,0 +1,19 @@
+package symbolic
+
+// Fold trims the expression and collapses every constant subtree into a single Constant.
+// Unlike Trim, named constants such as pi and e are replaced by their numeric value.
+func Fold(e Evaluatable) Evaluatable {
+	switch n := e.(type) {
+	case *Constant:
+		return GetConstantValue(n.value)
+	case operator:
+		left, right := n.operands()
+		if right != nil {
+			right = Fold(right)
+		}
+		// Trim folds operands that became numeric constants
+		return n.rebuild(Fold(left), right).Trim()
+	default:
+		return e.Trim()
+	}
+}
@@ -0,0 +1,56 @@
+package symbolic
+
+// Format renders the expression in infix notation keeping only the parentheses required
+// by precedence and associativity, so that Parse reads back an equivalent tree.
+// Negations are written as -u, and parenthesized on the right of a binary operator.
+// Right nested operands of the left associative operators keep their parentheses.
+// Use String for the fully parenthesized debug form.
+func Format(e Evaluatable) string {
+	text, _ := format(e)
+	return text
+}
+
+// Returns the infix form of e along with its precedence
+func format(e Evaluatable) (string, int) {
+	if u, ok := negated(e); ok {
+		return "-" + formatOperand(u, precedencePower), precedenceNegation
+	}
+	switch n := e.(type) {
+	case *Variable:
+		return n.name, precedenceAtom
+	case *Constant:
+		return n.name, precedenceAtom
+	case *add:
+		if u, ok := negated(n.right); ok {
+			return formatOperand(n.left, precedenceSum) + " - " + formatOperand(u, precedenceProduct), precedenceSum
+		}
+		return formatOperand(n.left, precedenceSum) + " + " + formatOperand(n.right, precedenceProduct), precedenceSum
+	case *sub:
+		return formatOperand(n.left, precedenceSum) + " - " + formatOperand(n.right, precedenceProduct), precedenceSum
+	case *multiply:
+		return formatOperand(n.left, precedenceNegation) + " * " + formatOperand(n.right, precedencePower), precedenceProduct
+	case *divide:
+		return formatOperand(n.left, precedenceNegation) + " / " + formatOperand(n.right, precedencePower), precedenceProduct
+	case *pow:
+		return formatOperand(n.left, precedenceAtom) + " ^ " + formatOperand(n.right, precedencePower), precedencePower
+	case operator:
+		left, right := n.operands()
+		args, _ := format(left)
+		if right != nil {
+			rightArgs, _ := format(right)
+			args += ", " + rightArgs
+		}
+		return n.symbol() + "(" + args + ")", precedenceAtom
+	default:
+		return e.String(), precedenceAtom
+	}
+}
+
+// Returns the infix form of e wrapped in parentheses if it binds weaker than precedence
+func formatOperand(e Evaluatable, precedence int) string {
+	text, p := format(e)
+	if p < precedence {
+		return "(" + text + ")"
+	}
+	return text
+}
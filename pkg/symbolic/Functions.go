@@ -22,6 +22,21 @@ func (p *pow) Evaluate() float64 {
 	return math.Pow(base, exp)
 }
 
+func (p *pow) EvaluateErr() (float64, error) {
+	return evaluateErr(p)
+}
+
+func (p *pow) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(p, env)
+}
+
+func (p *pow) apply(left, right float64) (float64, error) {
+	if left == 0.0 && right == 0.0 {
+		return 0.0, &ErrDomain{Func: "pow", Arg: left}
+	}
+	return math.Pow(left, right), nil
+}
+
 func (p *pow) Diff(v *Variable) Evaluatable {
 	leftIsFunc := p.left.FunctionOf(v)
 	rightIsFunc := p.right.FunctionOf(v)
@@ -73,8 +88,67 @@ func (p *pow) String() string {
 	return "(" + p.left.String() + " ^ " + p.right.String() + ")"
 }
 
+func (p *pow) symbol() string {
+	return "^"
+}
+
+func (p *pow) rebuild(left, right Evaluatable) Evaluatable {
+	return NodePow(left, right)
+}
+
 func (p *pow) Trim() Evaluatable {
-	return nil // TODO implement
+	// Simplifies a zero or one exponent
+	// Simplifies a one base
+	// Folds a pow of numeric constants
+	// Panic undetermined 0^0
+	leftTrim := p.left.Trim()
+	rightTrim := p.right.Trim()
+
+	// Boolean flags:
+	baseIsZero := false
+	baseIsOne := false
+	expIsZero := false
+	expIsOne := false
+	if cValue, ok := constantValue(leftTrim); ok {
+		if cValue == 0.0 {
+			baseIsZero = true
+		} else if cValue == 1.0 {
+			baseIsOne = true
+		}
+	}
+	if cValue, ok := constantValue(rightTrim); ok {
+		if cValue == 0.0 {
+			expIsZero = true
+		} else if cValue == 1.0 {
+			expIsOne = true
+		}
+	}
+
+	// Do the operations:
+	if baseIsZero && expIsZero {
+		panic("Expression contains undetermined 0^0!")
+	} else if expIsZero || baseIsOne {
+		return GetConstant(ConstantOne)
+	} else if expIsOne {
+		return leftTrim
+	} else if value, ok := foldPow(leftTrim, rightTrim); ok {
+		return GetConstantValue(value)
+	} else {
+		return NodePow(leftTrim, rightTrim)
+	}
+}
+
+// Returns base^exp and true if both are numeric constants with a real result
+func foldPow(base, exp Evaluatable) (float64, bool) {
+	bValue, eValue, ok := literalValues(base, exp)
+	if !ok {
+		return 0.0, false
+	}
+	value := math.Pow(bValue, eValue)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0.0, false
+	}
+	return value, true
 }
 
 type ln struct {
@@ -94,6 +168,21 @@ func (l *ln) Evaluate() float64 {
 	return math.Log(operand)
 }
 
+func (l *ln) EvaluateErr() (float64, error) {
+	return evaluateErr(l)
+}
+
+func (l *ln) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(l, env)
+}
+
+func (l *ln) apply(left, _ float64) (float64, error) {
+	if left <= 0.0 {
+		return 0.0, &ErrDomain{Func: "ln", Arg: left}
+	}
+	return math.Log(left), nil
+}
+
 func (l *ln) Diff(v *Variable) Evaluatable {
 	isFunc := l.left.FunctionOf(v)
 	if isFunc {
@@ -110,8 +199,38 @@ func (l *ln) String() string {
 	return "ln(" + l.left.String() + ")"
 }
 
+func (l *ln) symbol() string {
+	return "ln"
+}
+
+func (l *ln) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeLn(left)
+}
+
 func (l *ln) Trim() Evaluatable {
-	return nil // TODO implement
+	// Simplifies ln(1) = 0 and ln(e) = 1
+	// Simplifies ln(e^u) = u and ln(exp(u)) = u
+	// Folds ln of a positive numeric constant
+	operand := l.left.Trim()
+	if cValue, ok := constantValue(operand); ok {
+		if cValue == 1.0 {
+			return GetConstant(ConstantZero)
+		} else if cValue == math.E {
+			return GetConstant(ConstantOne)
+		}
+	}
+	if p, ok := operand.(*pow); ok {
+		if cValue, ok := constantValue(p.left); ok && cValue == math.E {
+			return p.right
+		}
+	}
+	if e, ok := operand.(*exp); ok {
+		return e.left
+	}
+	if value, ok := literalValue(operand); ok && value > 0.0 {
+		return GetConstantValue(math.Log(value))
+	}
+	return NodeLn(operand)
 }
 
 type sin struct {
@@ -127,6 +246,18 @@ func (s *sin) Evaluate() float64 {
 	return math.Sin(s.left.Evaluate())
 }
 
+func (s *sin) EvaluateErr() (float64, error) {
+	return evaluateErr(s)
+}
+
+func (s *sin) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(s, env)
+}
+
+func (s *sin) apply(left, _ float64) (float64, error) {
+	return math.Sin(left), nil
+}
+
 func (s *sin) Diff(v *Variable) Evaluatable {
 	isFunc := s.left.FunctionOf(v)
 	if isFunc {
@@ -143,8 +274,25 @@ func (s *sin) String() string {
 	return "sin(" + s.left.String() + ")"
 }
 
+func (s *sin) symbol() string {
+	return "sin"
+}
+
+func (s *sin) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeSin(left)
+}
+
 func (s *sin) Trim() Evaluatable {
-	return nil // TODO implement
+	// Simplifies sin(0) = 0
+	// Folds sin of a numeric constant
+	operand := s.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok {
+		return GetConstantValue(math.Sin(value))
+	}
+	return NodeSin(operand)
 }
 
 type cos struct {
@@ -160,14 +308,23 @@ func (c *cos) Evaluate() float64 {
 	return math.Cos(c.left.Evaluate())
 }
 
+func (c *cos) EvaluateErr() (float64, error) {
+	return evaluateErr(c)
+}
+
+func (c *cos) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(c, env)
+}
+
+func (c *cos) apply(left, _ float64) (float64, error) {
+	return math.Cos(left), nil
+}
+
 func (c *cos) Diff(v *Variable) Evaluatable {
 	isFunc := c.left.FunctionOf(v)
 	if isFunc {
 		return NodeMultiply(
-			NodeMultiply(
-				GetConstant(ConstantMinusOne),
-				NodeSin(c.left),
-			),
+			NodeNeg(NodeSin(c.left)),
 			c.left.Diff(v),
 		)
 	} else {
@@ -179,6 +336,358 @@ func (c *cos) String() string {
 	return "cos(" + c.left.String() + ")"
 }
 
+func (c *cos) symbol() string {
+	return "cos"
+}
+
+func (c *cos) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeCos(left)
+}
+
 func (c *cos) Trim() Evaluatable {
-	return nil // TODO implement
+	// Simplifies cos(0) = 1
+	// Folds cos of a numeric constant
+	operand := c.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantOne)
+	}
+	if value, ok := literalValue(operand); ok {
+		return GetConstantValue(math.Cos(value))
+	}
+	return NodeCos(operand)
+}
+
+type tan struct {
+	node
+}
+
+func NodeTan(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &tan{parent}
+}
+
+func (t *tan) Evaluate() float64 {
+	return math.Tan(t.left.Evaluate())
+}
+
+func (t *tan) EvaluateErr() (float64, error) {
+	return evaluateErr(t)
+}
+
+func (t *tan) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(t, env)
+}
+
+// Follows math.Tan: cos(x) is never exactly zero for a float64 x,
+// so there is no pole to reject and values near pi/2 are large but finite
+func (t *tan) apply(left, _ float64) (float64, error) {
+	return math.Tan(left), nil
+}
+
+func (t *tan) Diff(v *Variable) Evaluatable {
+	isFunc := t.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeDivide(
+				GetConstant(ConstantOne),
+				NodePow(NodeCos(t.left), GetConstantValue(2)),
+			),
+			t.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (t *tan) String() string {
+	return "tan(" + t.left.String() + ")"
+}
+
+func (t *tan) symbol() string {
+	return "tan"
+}
+
+func (t *tan) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeTan(left)
+}
+
+func (t *tan) Trim() Evaluatable {
+	// Simplifies tan(0) = 0
+	// Folds tan of a numeric constant
+	operand := t.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok {
+		return GetConstantValue(math.Tan(value))
+	}
+	return NodeTan(operand)
+}
+
+type exp struct {
+	node
+}
+
+func NodeExp(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &exp{parent}
+}
+
+func (e *exp) Evaluate() float64 {
+	return math.Exp(e.left.Evaluate())
+}
+
+func (e *exp) EvaluateErr() (float64, error) {
+	return evaluateErr(e)
+}
+
+func (e *exp) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(e, env)
+}
+
+func (e *exp) apply(left, _ float64) (float64, error) {
+	return math.Exp(left), nil
+}
+
+func (e *exp) Diff(v *Variable) Evaluatable {
+	isFunc := e.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(e, e.left.Diff(v))
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (e *exp) String() string {
+	return "exp(" + e.left.String() + ")"
+}
+
+func (e *exp) symbol() string {
+	return "exp"
+}
+
+func (e *exp) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeExp(left)
+}
+
+func (e *exp) Trim() Evaluatable {
+	// Simplifies exp(0) = 1 and exp(1) = e
+	// Folds exp of a numeric constant unless it overflows
+	operand := e.left.Trim()
+	if cValue, ok := constantValue(operand); ok {
+		if cValue == 0.0 {
+			return GetConstant(ConstantOne)
+		} else if cValue == 1.0 {
+			return GetConstant(ConstantE)
+		}
+	}
+	if value, ok := literalValue(operand); ok && !math.IsInf(math.Exp(value), 0) {
+		return GetConstantValue(math.Exp(value))
+	}
+	return NodeExp(operand)
+}
+
+type sqrt struct {
+	node
+}
+
+func NodeSqrt(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &sqrt{parent}
+}
+
+func (s *sqrt) Evaluate() float64 {
+	operand := s.left.Evaluate()
+	if operand < 0.0 {
+		panic("Negative domain for Sqrt")
+	}
+	return math.Sqrt(operand)
+}
+
+func (s *sqrt) EvaluateErr() (float64, error) {
+	return evaluateErr(s)
+}
+
+func (s *sqrt) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(s, env)
+}
+
+func (s *sqrt) apply(left, _ float64) (float64, error) {
+	if left < 0.0 {
+		return 0.0, &ErrDomain{Func: "sqrt", Arg: left}
+	}
+	return math.Sqrt(left), nil
+}
+
+func (s *sqrt) Diff(v *Variable) Evaluatable {
+	isFunc := s.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeDivide(
+				GetConstant(ConstantOne),
+				NodeMultiply(GetConstantValue(2), s),
+			),
+			s.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (s *sqrt) String() string {
+	return "sqrt(" + s.left.String() + ")"
+}
+
+func (s *sqrt) symbol() string {
+	return "sqrt"
+}
+
+func (s *sqrt) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeSqrt(left)
+}
+
+func (s *sqrt) Trim() Evaluatable {
+	// Simplifies sqrt(0) = 0 and sqrt(1) = 1
+	// Simplifies sqrt(u^2) = abs(u)
+	// Folds sqrt of a non negative numeric constant
+	operand := s.left.Trim()
+	if cValue, ok := constantValue(operand); ok {
+		if cValue == 0.0 {
+			return GetConstant(ConstantZero)
+		} else if cValue == 1.0 {
+			return GetConstant(ConstantOne)
+		}
+	}
+	if p, ok := operand.(*pow); ok {
+		if cValue, ok := constantValue(p.right); ok && cValue == 2.0 {
+			return NodeAbs(p.left)
+		}
+	}
+	if value, ok := literalValue(operand); ok && value >= 0.0 {
+		return GetConstantValue(math.Sqrt(value))
+	}
+	return NodeSqrt(operand)
+}
+
+type abs struct {
+	node
+}
+
+func NodeAbs(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &abs{parent}
+}
+
+func (a *abs) Evaluate() float64 {
+	return math.Abs(a.left.Evaluate())
+}
+
+func (a *abs) EvaluateErr() (float64, error) {
+	return evaluateErr(a)
+}
+
+func (a *abs) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(a, env)
+}
+
+func (a *abs) apply(left, _ float64) (float64, error) {
+	return math.Abs(left), nil
+}
+
+func (a *abs) Diff(v *Variable) Evaluatable {
+	isFunc := a.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(NodeSign(a.left), a.left.Diff(v))
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (a *abs) String() string {
+	return "abs(" + a.left.String() + ")"
+}
+
+func (a *abs) symbol() string {
+	return "abs"
+}
+
+func (a *abs) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeAbs(left)
+}
+
+func (a *abs) Trim() Evaluatable {
+	// Simplifies abs(abs(u)) = abs(u)
+	// Folds abs of a numeric constant
+	operand := a.left.Trim()
+	if inner, ok := operand.(*abs); ok {
+		return inner
+	}
+	if value, ok := literalValue(operand); ok {
+		return GetConstantValue(math.Abs(value))
+	}
+	return NodeAbs(operand)
+}
+
+// The sign function: -1, 0 or 1, the derivative of abs
+type sign struct {
+	node
+}
+
+func NodeSign(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &sign{parent}
+}
+
+func (s *sign) Evaluate() float64 {
+	value, _ := s.apply(s.left.Evaluate(), 0.0)
+	return value
+}
+
+func (s *sign) EvaluateErr() (float64, error) {
+	return evaluateErr(s)
+}
+
+func (s *sign) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(s, env)
+}
+
+func (s *sign) apply(left, _ float64) (float64, error) {
+	if left > 0.0 {
+		return 1.0, nil
+	} else if left < 0.0 {
+		return -1.0, nil
+	}
+	// Zero and NaN are their own sign
+	return left, nil
+}
+
+func (s *sign) Diff(v *Variable) Evaluatable {
+	// Zero wherever it is defined
+	return GetConstant(ConstantZero)
+}
+
+func (s *sign) String() string {
+	return "sign(" + s.left.String() + ")"
+}
+
+func (s *sign) symbol() string {
+	return "sign"
+}
+
+func (s *sign) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeSign(left)
+}
+
+func (s *sign) Trim() Evaluatable {
+	// Simplifies sign(sign(u)) = sign(u)
+	// Folds sign of a numeric constant
+	operand := s.left.Trim()
+	if inner, ok := operand.(*sign); ok {
+		return inner
+	}
+	if value, ok := literalValue(operand); ok {
+		value, _ = s.apply(value, 0.0)
+		return GetConstantValue(value)
+	}
+	return NodeSign(operand)
 }
@@ -0,0 +1,204 @@
+package symbolic
+
+import (
+	"fmt"
+	gofmt "go/format"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The syntax of Go, using the math package
+var goLanguage = language{
+	functions: map[string]string{
+		"^":     "math.Pow",
+		"ln":    "math.Log",
+		"sin":   "math.Sin",
+		"cos":   "math.Cos",
+		"tan":   "math.Tan",
+		"exp":   "math.Exp",
+		"sqrt":  "math.Sqrt",
+		"abs":   "math.Abs",
+		"sign":  "sign", // Declared by the function using it
+		"asin":  "math.Asin",
+		"acos":  "math.Acos",
+		"atan":  "math.Atan",
+		"atan2": "math.Atan2",
+		"sinh":  "math.Sinh",
+		"cosh":  "math.Cosh",
+		"tanh":  "math.Tanh",
+		"asinh": "math.Asinh",
+		"acosh": "math.Acosh",
+		"atanh": "math.Atanh",
+		"log10": "math.Log10",
+		"log2":  "math.Log2",
+	},
+	constants: map[string]string{
+		ConstantE:  "math.E",
+		ConstantPi: "math.Pi",
+	},
+	number: goNumber,
+	keywords: []string{
+		"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+		"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range",
+		"return", "select", "struct", "switch", "type", "var", "float64", "out",
+	},
+}
+
+// A GoFunction describes a function to generate with GenerateGo.
+// A single expression gives func Name(x, y float64) float64,
+// several give func Name(x, y float64, out []float64) writing out[i].
+type GoFunction struct {
+	Name  string
+	Vars  []*Variable // Parameters, in order
+	Exprs []Evaluatable
+}
+
+// GenerateGo returns the gofmt'ed source of a Go file of the given package with the functions.
+// Subexpressions used more than once are computed once into temporaries.
+// Constant subexpressions are emitted as their float64 value, as the tree evaluates them,
+// rather than left to the exact arithmetic of the Go compiler which rounds and overflows differently.
+// Returns the evaluation error of a constant subexpression that is not defined.
+func GenerateGo(pkg string, funcs ...GoFunction) ([]byte, error) {
+	if !isIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	var body strings.Builder
+	for _, f := range funcs {
+		source, err := f.source()
+		if err != nil {
+			return nil, err
+		}
+		body.WriteString("\n")
+		body.WriteString(source)
+	}
+
+	var file strings.Builder
+	file.WriteString("// Code generated by symbolic-algebra. DO NOT EDIT.\n\n")
+	file.WriteString("package " + pkg + "\n")
+	if strings.Contains(body.String(), "math.") {
+		file.WriteString("\nimport \"math\"\n")
+	}
+	file.WriteString(body.String())
+	return gofmt.Source([]byte(file.String()))
+}
+
+// Returns the unformatted source of the function
+func (f GoFunction) source() (string, error) {
+	if !isIdentifier(f.Name) {
+		return "", fmt.Errorf("invalid function name %q", f.Name)
+	}
+	if err := (CodeFunction{Name: f.Name, Exprs: f.Exprs}).validate(); err != nil {
+		return "", err
+	}
+	exprs := make([]Evaluatable, len(f.Exprs))
+	for i, expr := range f.Exprs {
+		folded, err := foldFloat(expr)
+		if err != nil {
+			return "", err
+		}
+		exprs[i] = folded
+	}
+	body, err := goLanguage.body(f.Vars, exprs)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	signature := strings.Join(body.params, ", ")
+	if len(body.params) > 0 {
+		signature += " float64"
+	}
+	multiple := len(f.Exprs) > 1
+	if multiple {
+		if len(body.params) > 0 {
+			signature += ", "
+		}
+		fmt.Fprintf(&b, "// %s writes its %d outputs into out\n", f.Name, len(f.Exprs))
+		fmt.Fprintf(&b, "func %s(%sout []float64) {\n", f.Name, signature)
+	} else {
+		fmt.Fprintf(&b, "// %s returns %s\n", f.Name, Format(f.Exprs[0]))
+		fmt.Fprintf(&b, "func %s(%s) float64 {\n", f.Name, signature)
+	}
+	if body.calls["sign"] {
+		b.WriteString(goSign)
+	}
+	for _, temp := range body.temporaries {
+		fmt.Fprintf(&b, "%s := %s\n", temp.name, temp.code)
+	}
+	for i, output := range body.outputs {
+		if multiple {
+			fmt.Fprintf(&b, "out[%d] = %s\n", i, output)
+		} else {
+			fmt.Fprintf(&b, "return %s\n", output)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// Replaces the constant operators of e by their value computed in float64
+func foldFloat(e Evaluatable) (Evaluatable, error) {
+	op, ok := e.(operator)
+	if !ok {
+		return e, nil
+	}
+	if op.IsConstant() {
+		value, err := op.EvaluateErr()
+		if err != nil {
+			return nil, err
+		}
+		return GetConstantValue(value), nil
+	}
+	left, right := op.operands()
+	left, err := foldFloat(left)
+	if err != nil {
+		return nil, err
+	}
+	if right != nil {
+		if right, err = foldFloat(right); err != nil {
+			return nil, err
+		}
+	}
+	// Go rejects a division by a constant zero, the tree fails on it at every point
+	if value, ok := literalValue(right); ok && value == 0.0 && op.symbol() == "/" {
+		return nil, &EvaluationError{Expr: op.String(), Err: ErrDivisionByZero}
+	}
+	return op.rebuild(left, right), nil
+}
+
+// The sign function, declared within the functions using it since math has none
+const goSign = `sign := func(x float64) float64 {
+	if x > 0 {
+		return 1
+	} else if x < 0 {
+		return -1
+	}
+	return x
+}
+`
+
+// Writes a float64 literal. Integral values get a decimal point so Go does not divide integers.
+func goNumber(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "math.NaN()"
+	case math.IsInf(v, 0):
+		if v > 0 {
+			return "math.Inf(1)"
+		}
+		return "math.Inf(-1)"
+	case v == 0.0 && math.Signbit(v):
+		return "math.Copysign(0, -1)"
+	}
+	return decimalNumber(v)
+}
+
+// Writes the shortest decimal literal of v, with a decimal point or an exponent
+func decimalNumber(v float64) string {
+	text := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".e") {
+		text += ".0"
+	}
+	return text
+}
@@ -0,0 +1,50 @@
+package symbolic
+
+// Returns the trimmed derivatives d expr / d vars[i].
+// Entries along variables the expression is not function of are the zero Constant.
+func Gradient(expr Evaluatable, vars []*Variable) []Evaluatable {
+	return CreateJacobian([]Evaluatable{expr}, vars).Entries[0]
+}
+
+// Returns the symmetric matrix of the second derivatives d2 expr / d vars[i] d vars[j].
+// Only the upper triangle is computed: entries [i][j] and [j][i] hold the same Evaluatable.
+func Hessian(expr Evaluatable, vars []*Variable) [][]Evaluatable {
+	d := CreateDerivatives(expr)
+	zero := GetConstant(ConstantZero)
+	hessian := make([][]Evaluatable, len(vars))
+	for i := range vars {
+		hessian[i] = make([]Evaluatable, len(vars))
+	}
+	for i, vi := range vars {
+		for j := i; j < len(vars); j++ {
+			entry := Evaluatable(zero)
+			if expr.FunctionOf(vi) && expr.FunctionOf(vars[j]) {
+				entry = d.Partial(vi, vars[j])
+			}
+			hessian[i][j] = entry
+			hessian[j][i] = entry
+		}
+	}
+	return hessian
+}
+
+// Evaluates the gradient with the given Env into dst, which must have its length
+func EvaluateGradient(gradient []Evaluatable, env Env, dst []float64) error {
+	return evaluateInto(gradient, env, dst)
+}
+
+// Evaluates the upper triangle of the Hessian with the given Env into dst and mirrors it,
+// dst must have the size of the matrix
+func EvaluateHessian(hessian [][]Evaluatable, env Env, dst [][]float64) error {
+	for i, row := range hessian {
+		for j := i; j < len(row); j++ {
+			value, err := row[j].EvaluateWith(env)
+			if err != nil {
+				return err
+			}
+			dst[i][j] = value
+			dst[j][i] = value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,403 @@
+package symbolic
+
+import (
+	"math"
+)
+
+type sinh struct {
+	node
+}
+
+func NodeSinh(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &sinh{parent}
+}
+
+func (s *sinh) Evaluate() float64 {
+	return math.Sinh(s.left.Evaluate())
+}
+
+func (s *sinh) EvaluateErr() (float64, error) {
+	return evaluateErr(s)
+}
+
+func (s *sinh) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(s, env)
+}
+
+func (s *sinh) apply(left, _ float64) (float64, error) {
+	return math.Sinh(left), nil
+}
+
+func (s *sinh) Diff(v *Variable) Evaluatable {
+	isFunc := s.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeCosh(s.left),
+			s.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (s *sinh) String() string {
+	return "sinh(" + s.left.String() + ")"
+}
+
+func (s *sinh) symbol() string {
+	return "sinh"
+}
+
+func (s *sinh) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeSinh(left)
+}
+
+func (s *sinh) Trim() Evaluatable {
+	// Simplifies sinh(0) = 0
+	// Folds sinh of a numeric constant unless it overflows
+	operand := s.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok && !math.IsInf(math.Sinh(value), 0) {
+		return GetConstantValue(math.Sinh(value))
+	}
+	return NodeSinh(operand)
+}
+
+type cosh struct {
+	node
+}
+
+func NodeCosh(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &cosh{parent}
+}
+
+func (c *cosh) Evaluate() float64 {
+	return math.Cosh(c.left.Evaluate())
+}
+
+func (c *cosh) EvaluateErr() (float64, error) {
+	return evaluateErr(c)
+}
+
+func (c *cosh) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(c, env)
+}
+
+func (c *cosh) apply(left, _ float64) (float64, error) {
+	return math.Cosh(left), nil
+}
+
+func (c *cosh) Diff(v *Variable) Evaluatable {
+	isFunc := c.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeSinh(c.left),
+			c.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (c *cosh) String() string {
+	return "cosh(" + c.left.String() + ")"
+}
+
+func (c *cosh) symbol() string {
+	return "cosh"
+}
+
+func (c *cosh) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeCosh(left)
+}
+
+func (c *cosh) Trim() Evaluatable {
+	// Simplifies cosh(0) = 1
+	// Folds cosh of a numeric constant unless it overflows
+	operand := c.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantOne)
+	}
+	if value, ok := literalValue(operand); ok && !math.IsInf(math.Cosh(value), 0) {
+		return GetConstantValue(math.Cosh(value))
+	}
+	return NodeCosh(operand)
+}
+
+type tanh struct {
+	node
+}
+
+func NodeTanh(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &tanh{parent}
+}
+
+func (t *tanh) Evaluate() float64 {
+	return math.Tanh(t.left.Evaluate())
+}
+
+func (t *tanh) EvaluateErr() (float64, error) {
+	return evaluateErr(t)
+}
+
+func (t *tanh) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(t, env)
+}
+
+func (t *tanh) apply(left, _ float64) (float64, error) {
+	return math.Tanh(left), nil
+}
+
+func (t *tanh) Diff(v *Variable) Evaluatable {
+	isFunc := t.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeDivide(
+				GetConstant(ConstantOne),
+				NodePow(NodeCosh(t.left), GetConstantValue(2)),
+			),
+			t.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (t *tanh) String() string {
+	return "tanh(" + t.left.String() + ")"
+}
+
+func (t *tanh) symbol() string {
+	return "tanh"
+}
+
+func (t *tanh) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeTanh(left)
+}
+
+func (t *tanh) Trim() Evaluatable {
+	// Simplifies tanh(0) = 0
+	// Folds tanh of a numeric constant
+	operand := t.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok {
+		return GetConstantValue(math.Tanh(value))
+	}
+	return NodeTanh(operand)
+}
+
+type asinh struct {
+	node
+}
+
+func NodeAsinh(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &asinh{parent}
+}
+
+func (a *asinh) Evaluate() float64 {
+	return math.Asinh(a.left.Evaluate())
+}
+
+func (a *asinh) EvaluateErr() (float64, error) {
+	return evaluateErr(a)
+}
+
+func (a *asinh) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(a, env)
+}
+
+func (a *asinh) apply(left, _ float64) (float64, error) {
+	return math.Asinh(left), nil
+}
+
+func (a *asinh) Diff(v *Variable) Evaluatable {
+	isFunc := a.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeDivide(
+				GetConstant(ConstantOne),
+				NodeSqrt(NodeAdd(NodePow(a.left, GetConstantValue(2)), GetConstant(ConstantOne))),
+			),
+			a.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (a *asinh) String() string {
+	return "asinh(" + a.left.String() + ")"
+}
+
+func (a *asinh) symbol() string {
+	return "asinh"
+}
+
+func (a *asinh) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeAsinh(left)
+}
+
+func (a *asinh) Trim() Evaluatable {
+	// Simplifies asinh(0) = 0
+	// Folds asinh of a numeric constant
+	operand := a.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok {
+		return GetConstantValue(math.Asinh(value))
+	}
+	return NodeAsinh(operand)
+}
+
+type acosh struct {
+	node
+}
+
+func NodeAcosh(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &acosh{parent}
+}
+
+func (a *acosh) Evaluate() float64 {
+	operand := a.left.Evaluate()
+	if operand < 1.0 {
+		panic("Domain of Acosh is [1, +Inf)")
+	}
+	return math.Acosh(operand)
+}
+
+func (a *acosh) EvaluateErr() (float64, error) {
+	return evaluateErr(a)
+}
+
+func (a *acosh) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(a, env)
+}
+
+func (a *acosh) apply(left, _ float64) (float64, error) {
+	if left < 1.0 {
+		return 0.0, &ErrDomain{Func: "acosh", Arg: left}
+	}
+	return math.Acosh(left), nil
+}
+
+func (a *acosh) Diff(v *Variable) Evaluatable {
+	isFunc := a.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeDivide(
+				GetConstant(ConstantOne),
+				NodeSqrt(NodeSub(NodePow(a.left, GetConstantValue(2)), GetConstant(ConstantOne))),
+			),
+			a.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (a *acosh) String() string {
+	return "acosh(" + a.left.String() + ")"
+}
+
+func (a *acosh) symbol() string {
+	return "acosh"
+}
+
+func (a *acosh) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeAcosh(left)
+}
+
+func (a *acosh) Trim() Evaluatable {
+	// Simplifies acosh(1) = 0
+	// Folds acosh of a numeric constant not less than 1
+	operand := a.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 1.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok && value >= 1.0 {
+		return GetConstantValue(math.Acosh(value))
+	}
+	return NodeAcosh(operand)
+}
+
+type atanh struct {
+	node
+}
+
+func NodeAtanh(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &atanh{parent}
+}
+
+func (a *atanh) Evaluate() float64 {
+	operand := a.left.Evaluate()
+	if operand <= -1.0 || operand >= 1.0 {
+		panic("Domain of Atanh is (-1, 1)")
+	}
+	return math.Atanh(operand)
+}
+
+func (a *atanh) EvaluateErr() (float64, error) {
+	return evaluateErr(a)
+}
+
+func (a *atanh) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(a, env)
+}
+
+func (a *atanh) apply(left, _ float64) (float64, error) {
+	if left <= -1.0 || left >= 1.0 {
+		return 0.0, &ErrDomain{Func: "atanh", Arg: left}
+	}
+	return math.Atanh(left), nil
+}
+
+func (a *atanh) Diff(v *Variable) Evaluatable {
+	isFunc := a.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeDivide(
+				GetConstant(ConstantOne),
+				NodeSub(GetConstant(ConstantOne), NodePow(a.left, GetConstantValue(2))),
+			),
+			a.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (a *atanh) String() string {
+	return "atanh(" + a.left.String() + ")"
+}
+
+func (a *atanh) symbol() string {
+	return "atanh"
+}
+
+func (a *atanh) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeAtanh(left)
+}
+
+func (a *atanh) Trim() Evaluatable {
+	// Simplifies atanh(0) = 0
+	// Folds atanh of a numeric constant in (-1, 1)
+	operand := a.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok && value > -1.0 && value < 1.0 {
+		return GetConstantValue(math.Atanh(value))
+	}
+	return NodeAtanh(operand)
+}
@@ -0,0 +1,286 @@
+package symbolic
+
+import (
+	"math"
+)
+
+type asin struct {
+	node
+}
+
+func NodeAsin(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &asin{parent}
+}
+
+func (a *asin) Evaluate() float64 {
+	operand := a.left.Evaluate()
+	if operand < -1.0 || operand > 1.0 {
+		panic("Domain of Asin is [-1, 1]")
+	}
+	return math.Asin(operand)
+}
+
+func (a *asin) EvaluateErr() (float64, error) {
+	return evaluateErr(a)
+}
+
+func (a *asin) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(a, env)
+}
+
+func (a *asin) apply(left, _ float64) (float64, error) {
+	if left < -1.0 || left > 1.0 {
+		return 0.0, &ErrDomain{Func: "asin", Arg: left}
+	}
+	return math.Asin(left), nil
+}
+
+func (a *asin) Diff(v *Variable) Evaluatable {
+	isFunc := a.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeDivide(GetConstant(ConstantOne), oneMinusSquare(a.left)),
+			a.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (a *asin) String() string {
+	return "asin(" + a.left.String() + ")"
+}
+
+func (a *asin) symbol() string {
+	return "asin"
+}
+
+func (a *asin) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeAsin(left)
+}
+
+func (a *asin) Trim() Evaluatable {
+	// Simplifies asin(0) = 0
+	// Folds asin of a numeric constant in [-1, 1]
+	operand := a.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok && value >= -1.0 && value <= 1.0 {
+		return GetConstantValue(math.Asin(value))
+	}
+	return NodeAsin(operand)
+}
+
+type acos struct {
+	node
+}
+
+func NodeAcos(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &acos{parent}
+}
+
+func (a *acos) Evaluate() float64 {
+	operand := a.left.Evaluate()
+	if operand < -1.0 || operand > 1.0 {
+		panic("Domain of Acos is [-1, 1]")
+	}
+	return math.Acos(operand)
+}
+
+func (a *acos) EvaluateErr() (float64, error) {
+	return evaluateErr(a)
+}
+
+func (a *acos) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(a, env)
+}
+
+func (a *acos) apply(left, _ float64) (float64, error) {
+	if left < -1.0 || left > 1.0 {
+		return 0.0, &ErrDomain{Func: "acos", Arg: left}
+	}
+	return math.Acos(left), nil
+}
+
+func (a *acos) Diff(v *Variable) Evaluatable {
+	isFunc := a.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeNeg(NodeDivide(GetConstant(ConstantOne), oneMinusSquare(a.left))),
+			a.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (a *acos) String() string {
+	return "acos(" + a.left.String() + ")"
+}
+
+func (a *acos) symbol() string {
+	return "acos"
+}
+
+func (a *acos) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeAcos(left)
+}
+
+func (a *acos) Trim() Evaluatable {
+	// Simplifies acos(1) = 0
+	// Folds acos of a numeric constant in [-1, 1]
+	operand := a.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 1.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok && value >= -1.0 && value <= 1.0 {
+		return GetConstantValue(math.Acos(value))
+	}
+	return NodeAcos(operand)
+}
+
+// Returns sqrt(1 - u^2), the denominator of the derivatives of asin and acos
+func oneMinusSquare(u Evaluatable) Evaluatable {
+	return NodeSqrt(NodeSub(GetConstant(ConstantOne), NodePow(u, GetConstantValue(2))))
+}
+
+type atan struct {
+	node
+}
+
+func NodeAtan(left Evaluatable) Evaluatable {
+	parent := node{left: left, right: nil}
+	return &atan{parent}
+}
+
+func (a *atan) Evaluate() float64 {
+	return math.Atan(a.left.Evaluate())
+}
+
+func (a *atan) EvaluateErr() (float64, error) {
+	return evaluateErr(a)
+}
+
+func (a *atan) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(a, env)
+}
+
+func (a *atan) apply(left, _ float64) (float64, error) {
+	return math.Atan(left), nil
+}
+
+func (a *atan) Diff(v *Variable) Evaluatable {
+	isFunc := a.left.FunctionOf(v)
+	if isFunc {
+		return NodeMultiply(
+			NodeDivide(
+				GetConstant(ConstantOne),
+				NodeAdd(GetConstant(ConstantOne), NodePow(a.left, GetConstantValue(2))),
+			),
+			a.left.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (a *atan) String() string {
+	return "atan(" + a.left.String() + ")"
+}
+
+func (a *atan) symbol() string {
+	return "atan"
+}
+
+func (a *atan) rebuild(left, _ Evaluatable) Evaluatable {
+	return NodeAtan(left)
+}
+
+func (a *atan) Trim() Evaluatable {
+	// Simplifies atan(0) = 0
+	// Folds atan of a numeric constant
+	operand := a.left.Trim()
+	if cValue, ok := constantValue(operand); ok && cValue == 0.0 {
+		return GetConstant(ConstantZero)
+	}
+	if value, ok := literalValue(operand); ok {
+		return GetConstantValue(math.Atan(value))
+	}
+	return NodeAtan(operand)
+}
+
+// The angle of the point (x, y): atan2(y, x) with left = y and right = x
+type atan2 struct {
+	node
+}
+
+func NodeAtan2(y, x Evaluatable) Evaluatable {
+	parent := node{left: y, right: x}
+	return &atan2{parent}
+}
+
+func (a *atan2) Evaluate() float64 {
+	y := a.left.Evaluate()
+	x := a.right.Evaluate()
+	if y == 0.0 && x == 0.0 {
+		panic("Undetermined atan2(0, 0)")
+	}
+	return math.Atan2(y, x)
+}
+
+func (a *atan2) EvaluateErr() (float64, error) {
+	return evaluateErr(a)
+}
+
+func (a *atan2) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(a, env)
+}
+
+func (a *atan2) apply(left, right float64) (float64, error) {
+	if left == 0.0 && right == 0.0 {
+		return 0.0, &ErrDomain{Func: "atan2", Arg: left}
+	}
+	return math.Atan2(left, right), nil
+}
+
+func (a *atan2) Diff(v *Variable) Evaluatable {
+	if !a.left.FunctionOf(v) && !a.right.FunctionOf(v) {
+		return GetConstant(ConstantZero)
+	}
+	// (x y' - y x') / (x^2 + y^2)
+	return NodeDivide(
+		NodeSub(
+			NodeMultiply(a.right, a.left.Diff(v)),
+			NodeMultiply(a.left, a.right.Diff(v)),
+		),
+		NodeAdd(
+			NodePow(a.right, GetConstantValue(2)),
+			NodePow(a.left, GetConstantValue(2)),
+		),
+	)
+}
+
+func (a *atan2) String() string {
+	return "atan2(" + a.left.String() + ", " + a.right.String() + ")"
+}
+
+func (a *atan2) symbol() string {
+	return "atan2"
+}
+
+func (a *atan2) rebuild(left, right Evaluatable) Evaluatable {
+	return NodeAtan2(left, right)
+}
+
+func (a *atan2) Trim() Evaluatable {
+	// Folds atan2 of numeric constants but atan2(0, 0)
+	leftTrim := a.left.Trim()
+	rightTrim := a.right.Trim()
+	if yValue, xValue, ok := literalValues(leftTrim, rightTrim); ok && (yValue != 0.0 || xValue != 0.0) {
+		return GetConstantValue(math.Atan2(yValue, xValue))
+	}
+	return NodeAtan2(leftTrim, rightTrim)
+}
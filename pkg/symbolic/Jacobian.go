@@ -0,0 +1,114 @@
+package symbolic
+
+// A JacobianMatrix holds the symbolic partial derivatives of a system of expressions
+type JacobianMatrix struct {
+	Entries [][]Evaluatable // Entries[i][j] is d exprs[i] / d vars[j], trimmed
+	Pattern [][]bool        // Pattern[i][j] is false when the entry is structurally zero
+	Vars    []*Variable
+}
+
+// A SparseMatrix of float64 in coordinate format
+type SparseMatrix struct {
+	Rows, Cols int
+	RowIndex   []int
+	ColIndex   []int
+	Values     []float64
+}
+
+// Returns the matrix of trimmed derivatives d exprs[i] / d vars[j]
+func Jacobian(exprs []Evaluatable, vars []*Variable) [][]Evaluatable {
+	return CreateJacobian(exprs, vars).Entries
+}
+
+// Builds the JacobianMatrix of exprs with respect to vars.
+// Entries of expressions that are not function of a variable are not differentiated.
+func CreateJacobian(exprs []Evaluatable, vars []*Variable) *JacobianMatrix {
+	j := &JacobianMatrix{
+		Entries: make([][]Evaluatable, len(exprs)),
+		Pattern: make([][]bool, len(exprs)),
+		Vars:    vars,
+	}
+	for row, expr := range exprs {
+		j.Entries[row] = make([]Evaluatable, len(vars))
+		j.Pattern[row] = make([]bool, len(vars))
+		for col, v := range vars {
+			if expr.FunctionOf(v) {
+				j.Entries[row][col] = expr.Diff(v).Trim()
+				j.Pattern[row][col] = true
+			} else {
+				j.Entries[row][col] = GetConstant(ConstantZero)
+			}
+		}
+	}
+	return j
+}
+
+// Returns the number of structurally non zero entries
+func (j *JacobianMatrix) NonZeros() int {
+	count := 0
+	for _, row := range j.Pattern {
+		for _, nonZero := range row {
+			if nonZero {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Evaluates every entry with the given Env into a new dense matrix
+func (j *JacobianMatrix) Evaluate(env Env) ([][]float64, error) {
+	dst := make([][]float64, len(j.Entries))
+	for row := range dst {
+		dst[row] = make([]float64, len(j.Vars))
+	}
+	if err := j.EvaluateInto(env, dst); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// Evaluates every entry with the given Env into dst, which must have the size of the matrix
+func (j *JacobianMatrix) EvaluateInto(env Env, dst [][]float64) error {
+	for row, entries := range j.Entries {
+		for col, entry := range entries {
+			if !j.Pattern[row][col] {
+				dst[row][col] = 0.0
+				continue
+			}
+			value, err := entry.EvaluateWith(env)
+			if err != nil {
+				return err
+			}
+			dst[row][col] = value
+		}
+	}
+	return nil
+}
+
+// Evaluates the structurally non zero entries with the given Env into a SparseMatrix
+func (j *JacobianMatrix) EvaluateSparse(env Env) (*SparseMatrix, error) {
+	nonZeros := j.NonZeros()
+	m := &SparseMatrix{
+		Rows:     len(j.Entries),
+		Cols:     len(j.Vars),
+		RowIndex: make([]int, 0, nonZeros),
+		ColIndex: make([]int, 0, nonZeros),
+		Values:   make([]float64, 0, nonZeros),
+	}
+	for row, entries := range j.Entries {
+		for col, entry := range entries {
+			if !j.Pattern[row][col] {
+				continue
+			}
+			value, err := entry.EvaluateWith(env)
+			if err != nil {
+				return nil, err
+			}
+			m.RowIndex = append(m.RowIndex, row)
+			m.ColIndex = append(m.ColIndex, col)
+			m.Values = append(m.Values, value)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,138 @@
+package symbolic
+
+import (
+	"strings"
+)
+
+// LaTeX renders the expression in LaTeX math mode with as few parentheses as possible
+func LaTeX(e Evaluatable) string {
+	text, _ := latex(e)
+	return text
+}
+
+// Returns the LaTeX of e along with its precedence
+func latex(e Evaluatable) (string, int) {
+	if u, ok := negated(e); ok {
+		return "-" + latexOperand(u, precedenceProduct), precedenceNegation
+	}
+	switch n := e.(type) {
+	case *Variable:
+		return n.name, precedenceAtom
+	case *Constant:
+		return latexConstant(n), precedenceAtom
+	case *add:
+		if u, ok := negated(n.right); ok {
+			return latexOperand(n.left, precedenceSum) + " - " + latexOperand(u, precedenceNegation+1), precedenceSum
+		}
+		return latexOperand(n.left, precedenceSum) + " + " + latexOperand(n.right, precedenceSum), precedenceSum
+	case *sub:
+		return latexOperand(n.left, precedenceSum) + " - " + latexOperand(n.right, precedenceNegation+1), precedenceSum
+	case *multiply:
+		left := latexOperand(n.left, precedenceNegation)
+		right := latexOperand(n.right, precedenceProduct)
+		if juxtapose(left, right) {
+			return left + right, precedenceProduct
+		}
+		return left + " \\cdot " + right, precedenceProduct
+	case *divide:
+		left, _ := latex(n.left)
+		right, _ := latex(n.right)
+		return "\\frac{" + left + "}{" + right + "}", precedenceAtom
+	case *pow:
+		base, _ := latex(n.left)
+		if !isLeaf(n.left) || strings.ContainsAny(base, "- ") {
+			base = "\\left(" + base + "\\right)"
+		}
+		exp, _ := latex(n.right)
+		return base + "^{" + exp + "}", precedencePower
+	case operator:
+		return latexFunction(n), precedenceAtom
+	default:
+		return e.String(), precedenceAtom
+	}
+}
+
+// Returns the LaTeX of e wrapped in parentheses if it binds weaker than precedence
+func latexOperand(e Evaluatable, precedence int) string {
+	text, p := latex(e)
+	if p < precedence {
+		return "\\left(" + text + "\\right)"
+	}
+	return text
+}
+
+// Renders a function of one or two arguments, like \sin\left(x\right)
+func latexFunction(op operator) string {
+	left, right := op.operands()
+	args, _ := latex(left)
+	name := op.symbol()
+	switch name {
+	case "sqrt":
+		return "\\sqrt{" + args + "}"
+	case "log":
+		arg, _ := latex(right)
+		return "\\log_{" + args + "}\\left(" + arg + "\\right)"
+	case "abs":
+		return "\\left|" + args + "\\right|"
+	case "ln", "sin", "cos", "tan", "exp", "sinh", "cosh", "tanh":
+		name = "\\" + name
+	case "asin", "acos", "atan":
+		name = "\\arc" + name[1:]
+	case "asinh", "acosh", "atanh":
+		name = "\\operatorname{ar" + name[1:] + "}"
+	case "sign":
+		name = "\\operatorname{sgn}"
+	default:
+		name = "\\operatorname{" + name + "}"
+	}
+	if right != nil {
+		rightArgs, _ := latex(right)
+		args += ", " + rightArgs
+	}
+	return name + "\\left(" + args + "\\right)"
+}
+
+// Renders pi as \pi and numbers in scientific notation as 1.5 \cdot 10^{-5}
+func latexConstant(c *Constant) string {
+	if c.name == ConstantPi {
+		return "\\pi"
+	}
+	if _, ok := literalValue(c); ok {
+		if mantissa, exp, found := strings.Cut(c.name, "e"); found {
+			exp = strings.TrimPrefix(exp, "+")
+			negative := strings.HasPrefix(exp, "-")
+			exp = strings.TrimLeft(strings.TrimPrefix(exp, "-"), "0")
+			if negative {
+				exp = "-" + exp
+			}
+			return mantissa + " \\cdot 10^{" + exp + "}"
+		}
+	}
+	return c.name
+}
+
+// Returns true if e is a Variable or a Constant
+func isLeaf(e Evaluatable) bool {
+	switch e.(type) {
+	case *Variable, *Constant:
+		return true
+	}
+	return false
+}
+
+// Returns true if two factors can be written next to each other without \cdot.
+// That is a number followed by a name, or anything followed by a command like \pi or \sin.
+// A number followed by a fraction keeps its \cdot, 2\frac{1}{x} would read as a mixed number.
+func juxtapose(left, right string) bool {
+	if right == "" || strings.ContainsAny(right[:1], "0123456789.-") {
+		return false
+	}
+	last := left[len(left)-1]
+	if strings.HasPrefix(right, "\\frac") {
+		return !isDigit(last)
+	}
+	if strings.HasPrefix(right, "\\") && !strings.HasPrefix(right, "\\left") {
+		return true
+	}
+	return isDigit(last) && !strings.Contains(left, "\\cdot")
+}
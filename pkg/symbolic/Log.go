@@ -0,0 +1,237 @@
+package symbolic
+
+import (
+	"math"
+)
+
+// The logarithm of right in the base left
+type log struct {
+	node
+}
+
+func NodeLog(base, arg Evaluatable) Evaluatable {
+	parent := node{left: base, right: arg}
+	return &log{parent}
+}
+
+// Returns the base 10 logarithm of arg
+func NodeLog10(arg Evaluatable) Evaluatable {
+	return NodeLog(GetConstantValue(10), arg)
+}
+
+// Returns the base 2 logarithm of arg
+func NodeLog2(arg Evaluatable) Evaluatable {
+	return NodeLog(GetConstantValue(2), arg)
+}
+
+func (l *log) Evaluate() float64 {
+	value, err := l.apply(l.left.Evaluate(), l.right.Evaluate())
+	if err != nil {
+		panic("Invalid domain for Log: " + err.Error())
+	}
+	return value
+}
+
+func (l *log) EvaluateErr() (float64, error) {
+	return evaluateErr(l)
+}
+
+func (l *log) EvaluateWith(env Env) (float64, error) {
+	return evaluateWith(l, env)
+}
+
+func (l *log) apply(left, right float64) (float64, error) {
+	if left <= 0.0 || left == 1.0 {
+		return 0.0, &ErrDomain{Func: "log", Arg: left}
+	}
+	if right <= 0.0 {
+		return 0.0, &ErrDomain{Func: "log", Arg: right}
+	}
+	// Exact results for the usual bases
+	if left == 10.0 {
+		return math.Log10(right), nil
+	} else if left == 2.0 {
+		return math.Log2(right), nil
+	}
+	return math.Log(right) / math.Log(left), nil
+}
+
+func (l *log) Diff(v *Variable) Evaluatable {
+	baseIsFunc := l.left.FunctionOf(v)
+	argIsFunc := l.right.FunctionOf(v)
+	if baseIsFunc {
+		// Change of base: log_b(u) = ln(u) / ln(b)
+		return NodeDivide(NodeLn(l.right), NodeLn(l.left)).Diff(v)
+	} else if argIsFunc {
+		return NodeMultiply(
+			NodeDivide(
+				GetConstant(ConstantOne),
+				NodeMultiply(l.right, NodeLn(l.left)),
+			),
+			l.right.Diff(v),
+		)
+	} else {
+		return GetConstant(ConstantZero)
+	}
+}
+
+func (l *log) String() string {
+	return "log(" + l.left.String() + ", " + l.right.String() + ")"
+}
+
+func (l *log) symbol() string {
+	return "log"
+}
+
+func (l *log) rebuild(left, right Evaluatable) Evaluatable {
+	return NodeLog(left, right)
+}
+
+func (l *log) Trim() Evaluatable {
+	// Simplifies log_b(1) = 0 and log_b(b) = 1
+	// Simplifies log_b(b^u) = u
+	// Simplifies log_e(u) = ln(u)
+	// Folds log of numeric constants in the domain
+	base := l.left.Trim()
+	arg := l.right.Trim()
+	if cValue, ok := constantValue(arg); ok && cValue == 1.0 {
+		return GetConstant(ConstantZero)
+	}
+	if Equal(base, arg) {
+		return GetConstant(ConstantOne)
+	}
+	if p, ok := arg.(*pow); ok && Equal(p.left, base) {
+		return p.right
+	}
+	if cValue, ok := constantValue(base); ok && cValue == math.E {
+		return NodeLn(arg).Trim()
+	}
+	if bValue, aValue, ok := literalValues(base, arg); ok {
+		if value, err := l.apply(bValue, aValue); err == nil {
+			return GetConstantValue(value)
+		}
+	}
+	return NodeLog(base, arg)
+}
+
+// ExpandLog rewrites the logarithms of products, quotients and powers:
+// ln(a * b) = ln(a) + ln(b), ln(a / b) = ln(a) - ln(b) and ln(a^n) = n * ln(a),
+// and likewise in any base. The rules assume a and b are positive.
+func ExpandLog(e Evaluatable) Evaluatable {
+	op, ok := e.(operator)
+	if !ok {
+		return e
+	}
+	left, right := op.operands()
+	left = ExpandLog(left)
+	if right != nil {
+		right = ExpandLog(right)
+	}
+	switch op.(type) {
+	case *ln:
+		return expandLog(nil, left)
+	case *log:
+		return expandLog(left, right)
+	default:
+		return op.rebuild(left, right)
+	}
+}
+
+// Expands the logarithm of arg in the given base, nil for the natural one
+func expandLog(base, arg Evaluatable) Evaluatable {
+	switch a := arg.(type) {
+	case *multiply:
+		return NodeAdd(expandLog(base, a.left), expandLog(base, a.right))
+	case *divide:
+		return NodeSub(expandLog(base, a.left), expandLog(base, a.right))
+	case *pow:
+		return NodeMultiply(a.right, expandLog(base, a.left))
+	default:
+		return logOf(base, arg)
+	}
+}
+
+// ContractLog reverses ExpandLog: ln(a) + ln(b) becomes ln(a * b), ln(a) - ln(b) becomes ln(a / b)
+// and c * ln(a) becomes ln(a^c) for a constant c, and likewise in any base
+func ContractLog(e Evaluatable) Evaluatable {
+	op, ok := e.(operator)
+	if !ok {
+		return e
+	}
+	left, right := op.operands()
+	left = ContractLog(left)
+	if right != nil {
+		right = ContractLog(right)
+	}
+	switch op.(type) {
+	case *add:
+		if base, a, b, ok := sameBaseLogs(left, right); ok {
+			return logOf(base, NodeMultiply(a, b))
+		}
+	case *sub:
+		if base, a, b, ok := sameBaseLogs(left, right); ok {
+			return logOf(base, NodeDivide(a, b))
+		}
+	case *multiply:
+		if base, a, ok := logParts(right); ok && left.IsConstant() {
+			return logOf(base, NodePow(a, left))
+		}
+		if base, a, ok := logParts(left); ok && right.IsConstant() {
+			return logOf(base, NodePow(a, right))
+		}
+	}
+	return op.rebuild(left, right)
+}
+
+// CancelLog rewrites exp(ln(u)) as u. The rule assumes u is positive:
+// exp(ln(u)) is not defined for the other values, unlike the u that replaces it.
+func CancelLog(e Evaluatable) Evaluatable {
+	op, ok := e.(operator)
+	if !ok {
+		return e
+	}
+	left, right := op.operands()
+	left = CancelLog(left)
+	if right != nil {
+		right = CancelLog(right)
+	}
+	if _, ok := op.(*exp); ok {
+		if l, ok := left.(*ln); ok {
+			return l.left
+		}
+	}
+	return op.rebuild(left, right)
+}
+
+// Returns the base and the argument of a logarithm, a nil base for ln
+func logParts(e Evaluatable) (base, arg Evaluatable, ok bool) {
+	switch n := e.(type) {
+	case *ln:
+		return nil, n.left, true
+	case *log:
+		return n.left, n.right, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// Returns the common base and the arguments of two logarithms in the same base
+func sameBaseLogs(l, r Evaluatable) (base, a, b Evaluatable, ok bool) {
+	lBase, a, lOk := logParts(l)
+	rBase, b, rOk := logParts(r)
+	if !lOk || !rOk {
+		return nil, nil, nil, false
+	}
+	if lBase == nil || rBase == nil {
+		return nil, a, b, lBase == nil && rBase == nil
+	}
+	return lBase, a, b, Equal(lBase, rBase)
+}
+
+// Returns the logarithm of arg in the given base, ln for a nil base
+func logOf(base, arg Evaluatable) Evaluatable {
+	if base == nil {
+		return NodeLn(arg)
+	}
+	return NodeLog(base, arg)
+}
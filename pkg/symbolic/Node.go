@@ -33,3 +33,17 @@ func (n *node) IsConstant() bool {
 		return true
 	}
 }
+
+// An operator is a node of the tree with one or two operands
+type operator interface {
+	Evaluatable
+	operands() (left, right Evaluatable)
+	rebuild(left, right Evaluatable) Evaluatable
+	apply(left, right float64) (float64, error)
+	symbol() string
+}
+
+// Returns the operands of the node, right is nil for functions of a single argument
+func (n *node) operands() (Evaluatable, Evaluatable) {
+	return n.left, n.right
+}
@@ -0,0 +1,372 @@
+package symbolic
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// A pool of the functions understood by the parser, indexed by name
+var functionPool = map[string]func(Evaluatable) Evaluatable{
+	"ln":    NodeLn,
+	"sin":   NodeSin,
+	"cos":   NodeCos,
+	"tan":   NodeTan,
+	"exp":   NodeExp,
+	"sqrt":  NodeSqrt,
+	"abs":   NodeAbs,
+	"sign":  NodeSign,
+	"asin":  NodeAsin,
+	"acos":  NodeAcos,
+	"atan":  NodeAtan,
+	"sinh":  NodeSinh,
+	"cosh":  NodeCosh,
+	"tanh":  NodeTanh,
+	"asinh": NodeAsinh,
+	"acosh": NodeAcosh,
+	"atanh": NodeAtanh,
+	"log10": NodeLog10,
+	"log2":  NodeLog2,
+}
+
+// The functions of two arguments the parser knows about
+var binaryFunctionPool = map[string]func(Evaluatable, Evaluatable) Evaluatable{
+	"atan2": NodeAtan2,
+	"log":   NodeLog,
+}
+
+// A ParseError reports where the parser stopped and the offending token
+type ParseError struct {
+	Offset int    // Byte offset of the token in the input
+	Token  string // Unexpected token, empty at the end of input
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("parse error at offset %d: %s", e.Offset, e.Msg)
+	}
+	return fmt.Sprintf("parse error at offset %d near %q: %s", e.Offset, e.Token, e.Msg)
+}
+
+// Parse builds an expression tree from an infix string such as "2 * sin(x) ^ 2".
+// Supports + - * / ^, unary minus, parentheses, numeric literals, the constants e and pi
+// and the functions in functionPool and binaryFunctionPool.
+// Every other name becomes a Variable, one per name.
+func Parse(input string) (Evaluatable, error) {
+	return ParseWith(input)
+}
+
+// ParseWith works like Parse but reuses the given Variables when their names show up.
+// The given Variables take precedence over constants of the same name.
+func ParseWith(input string, vars ...*Variable) (Evaluatable, error) {
+	pool := make(map[string]*Variable)
+	for _, v := range vars {
+		pool[v.name] = v
+	}
+	expr, _, err := parseInto(input, pool)
+	return expr, err
+}
+
+// Parses the input looking up and adding Variables to the given pool.
+// Returns the Variables that were created along the expression.
+func parseInto(input string, pool map[string]*Variable) (Evaluatable, []*Variable, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := parser{tokens: tokens, vars: pool}
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEnd {
+		return nil, nil, tok.errorf("unexpected token")
+	}
+	return expr, p.created, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+)
+
+// A token of the input string along with its byte offset
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func (t token) errorf(format string, args ...interface{}) *ParseError {
+	return &ParseError{Offset: t.offset, Token: t.text, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Splits the input in numbers, identifiers and single character operators
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(input) {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case isDigit(input[i]) || c == '.':
+			start := i
+			i = scanNumber(input, i)
+			tokens = append(tokens, token{tokenNumber, input[start:i], start})
+		case isLetter(input[i]):
+			start := i
+			for i < len(input) && isIdentChar(input[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, input[start:i], start})
+		case c == '+' || c == '-' || c == '*' || c == '/' || c == '^' ||
+			c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{tokenOperator, input[i : i+1], i})
+			i++
+		default:
+			return nil, &ParseError{Offset: i, Token: input[i : i+1], Msg: "invalid character"}
+		}
+	}
+	tokens = append(tokens, token{tokenEnd, "", len(input)})
+	return tokens, nil
+}
+
+// Returns the offset right after the number starting at i
+func scanNumber(input string, i int) int {
+	for i < len(input) && isDigit(input[i]) {
+		i++
+	}
+	if i < len(input) && input[i] == '.' {
+		i++
+		for i < len(input) && isDigit(input[i]) {
+			i++
+		}
+	}
+	// Only take the exponent if it is complete, so "2e" is left as 2 and e
+	if i < len(input) && (input[i] == 'e' || input[i] == 'E') {
+		j := i + 1
+		if j < len(input) && (input[j] == '+' || input[j] == '-') {
+			j++
+		}
+		if j < len(input) && isDigit(input[j]) {
+			for j < len(input) && isDigit(input[j]) {
+				j++
+			}
+			i = j
+		}
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isLetter(c) || isDigit(c)
+}
+
+// A recursive descent parser over a tokenized input
+type parser struct {
+	tokens  []token
+	pos     int
+	vars    map[string]*Variable
+	created []*Variable
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEnd {
+		p.pos++
+	}
+	return tok
+}
+
+// Returns true and consumes the next token if it is the given operator
+func (p *parser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokenOperator && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		if tok.kind == tokenEnd {
+			return tok.errorf("expected %q, got end of input", op)
+		}
+		return tok.errorf("expected %q", op)
+	}
+	return nil
+}
+
+// expression := term (("+" | "-") term)*
+func (p *parser) parseExpression() (Evaluatable, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.accept("+") {
+			right, err := p.parseTerm()
+			if err != nil {
+				return nil, err
+			}
+			left = NodeAdd(left, right)
+		} else if p.accept("-") {
+			right, err := p.parseTerm()
+			if err != nil {
+				return nil, err
+			}
+			left = NodeSub(left, right)
+		} else {
+			return left, nil
+		}
+	}
+}
+
+// term := unary (("*" | "/") unary)*
+func (p *parser) parseTerm() (Evaluatable, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.accept("*") {
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			left = NodeMultiply(left, right)
+		} else if p.accept("/") {
+			right, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			left = NodeDivide(left, right)
+		} else {
+			return left, nil
+		}
+	}
+}
+
+// unary := "-" unary | power
+func (p *parser) parseUnary() (Evaluatable, error) {
+	if p.accept("-") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return NodeNeg(operand), nil
+	}
+	return p.parsePower()
+}
+
+// power := primary ("^" unary)?, which makes "^" right associative
+func (p *parser) parsePower() (Evaluatable, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.accept("^") {
+		exp, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return NodePow(base, exp), nil
+	}
+	return base, nil
+}
+
+// primary := number | name | name "(" expression ("," expression)? ")" | "(" expression ")"
+func (p *parser) parsePrimary() (Evaluatable, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, tok.errorf("invalid number")
+		}
+		return GetConstantValue(value), nil
+	case tokenIdent:
+		if p.accept("(") {
+			return p.parseCall(tok)
+		}
+		if v, ok := p.vars[tok.text]; ok {
+			return v, nil
+		}
+		if _, ok := constantPool[tok.text]; ok {
+			return GetConstant(tok.text), nil
+		}
+		if _, ok := functionPool[tok.text]; ok {
+			return nil, tok.errorf("function %s requires an argument", tok.text)
+		}
+		if _, ok := binaryFunctionPool[tok.text]; ok {
+			return nil, tok.errorf("function %s requires two arguments", tok.text)
+		}
+		v := CreateVariable(tok.text)
+		p.vars[tok.text] = v
+		p.created = append(p.created, v)
+		return v, nil
+	case tokenOperator:
+		if tok.text == "(" {
+			expr, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return expr, nil
+		}
+		return nil, tok.errorf("unexpected operator")
+	default:
+		return nil, tok.errorf("unexpected end of input")
+	}
+}
+
+// Parses the arguments of a function call, the opening parenthesis is already consumed
+func (p *parser) parseCall(name token) (Evaluatable, error) {
+	function, unary := functionPool[name.text]
+	binary, ok := binaryFunctionPool[name.text]
+	if !unary && !ok {
+		return nil, name.errorf("unknown function")
+	}
+	arg, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if unary {
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return function(arg), nil
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	second, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return binary(arg, second), nil
+}
@@ -0,0 +1,26 @@
+package symbolic
+
+// Binding strength of the operators, used by the printers to drop parentheses
+const (
+	precedenceSum = iota + 1
+	precedenceNegation
+	precedenceProduct
+	precedencePower
+	precedenceAtom
+)
+
+// Returns u and true if e is the negation -u or -1 * u, or -c for a negative numeric constant c
+func negated(e Evaluatable) (Evaluatable, bool) {
+	if n, ok := e.(*neg); ok {
+		return n.left, true
+	}
+	if m, ok := e.(*multiply); ok {
+		if value, ok := literalValue(m.left); ok && value == -1.0 {
+			return m.right, true
+		}
+	}
+	if value, ok := literalValue(e); ok && value < 0.0 {
+		return GetConstantValue(-value), true
+	}
+	return nil, false
+}
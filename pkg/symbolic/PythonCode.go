@@ -0,0 +1,104 @@
+package symbolic
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// The syntax of Python, using NumPy imported as np so that arguments can be arrays
+var pythonLanguage = language{
+	functions: map[string]string{
+		"ln":    "np.log",
+		"sin":   "np.sin",
+		"cos":   "np.cos",
+		"tan":   "np.tan",
+		"exp":   "np.exp",
+		"sqrt":  "np.sqrt",
+		"abs":   "np.abs",
+		"sign":  "np.sign",
+		"asin":  "np.arcsin",
+		"acos":  "np.arccos",
+		"atan":  "np.arctan",
+		"atan2": "np.arctan2",
+		"sinh":  "np.sinh",
+		"cosh":  "np.cosh",
+		"tanh":  "np.tanh",
+		"asinh": "np.arcsinh",
+		"acosh": "np.arccosh",
+		"atanh": "np.arctanh",
+		"log10": "np.log10",
+		"log2":  "np.log2",
+	},
+	power: "**",
+	constants: map[string]string{
+		ConstantE:  "np.e",
+		ConstantPi: "np.pi",
+	},
+	number: pythonNumber,
+	keywords: []string{
+		"False", "None", "True", "and", "as", "assert", "async", "await", "break", "class",
+		"continue", "def", "del", "elif", "else", "except", "finally", "for", "from", "global",
+		"if", "import", "in", "is", "lambda", "nonlocal", "not", "or", "pass", "raise",
+		"return", "try", "while", "with", "yield",
+	},
+}
+
+// PythonExpr writes the expression in Python with NumPy, with its Variables by name
+func PythonExpr(e Evaluatable, opts *CodeOptions) (string, error) {
+	c := coder{lang: pythonLanguage.with(opts)}
+	return c.code(e)
+}
+
+// GeneratePython returns a Python module importing numpy as np with the functions.
+// A single expression gives def Name(x, y) returning a number,
+// vectors and matrices return np.array of the outputs.
+func GeneratePython(opts *CodeOptions, funcs ...CodeFunction) (string, error) {
+	lang := pythonLanguage.with(opts)
+	var b strings.Builder
+	b.WriteString("import numpy as np\n")
+	for _, f := range funcs {
+		if !isIdentifier(f.Name) || lang.reserved()[f.Name] {
+			return "", fmt.Errorf("invalid function name %q", f.Name)
+		}
+		if err := f.validate(); err != nil {
+			return "", err
+		}
+		body, err := lang.body(f.Vars, f.Exprs)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&b, "\n\ndef %s(%s):\n", f.Name, strings.Join(body.params, ", "))
+		for _, temp := range body.temporaries {
+			fmt.Fprintf(&b, "    %s = %s\n", temp.name, temp.code)
+		}
+		switch {
+		case f.Cols > 0:
+			rows := make([]string, 0, len(body.outputs)/f.Cols)
+			for i := 0; i < len(body.outputs); i += f.Cols {
+				rows = append(rows, "["+strings.Join(body.outputs[i:i+f.Cols], ", ")+"]")
+			}
+			fmt.Fprintf(&b, "    return np.array([%s])\n", strings.Join(rows, ", "))
+		case len(body.outputs) > 1:
+			fmt.Fprintf(&b, "    return np.array([%s])\n", strings.Join(body.outputs, ", "))
+		default:
+			fmt.Fprintf(&b, "    return %s\n", body.outputs[0])
+		}
+	}
+	return b.String(), nil
+}
+
+// Writes a float literal, using NumPy for infinities and NaN
+func pythonNumber(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "np.nan"
+	case math.IsInf(v, 0):
+		if v > 0 {
+			return "np.inf"
+		}
+		return "-np.inf"
+	}
+	return decimalNumber(v)
+}
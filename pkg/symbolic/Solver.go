@@ -0,0 +1,221 @@
+package symbolic
+
+import (
+	"fmt"
+	"math"
+)
+
+// Options of the Newton-Raphson solver, zero values take the defaults
+type SolverOptions struct {
+	MaxIterations int     // Iteration limit, 50 by default
+	ResidualTol   float64 // Converged when the residual norm is below, 1e-10 by default
+	StepTol       float64 // Stops when the step norm is below, relative to the solution, 1e-12 by default
+	MinDamping    float64 // Smallest step fraction tried by the line search, 1e-4 by default
+}
+
+// The state of the solver after one iteration
+type SolverIteration struct {
+	Residual float64 // Residual norm before the step
+	Step     float64 // Norm of the damped step
+	Damping  float64 // Fraction of the Newton step that was taken
+}
+
+// The outcome of Solve
+type SolverResult struct {
+	Solution   Env // Values of the unknowns, along with the parameters of the initial guess
+	Iterations int
+	Residual   float64 // Final residual norm
+	Converged  bool    // The final residual norm is within ResidualTol
+	Trace      []SolverIteration
+}
+
+// Returns a copy of the options with the zero values replaced by the defaults
+func (o *SolverOptions) withDefaults() SolverOptions {
+	opts := SolverOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = 50
+	}
+	if opts.ResidualTol <= 0.0 {
+		opts.ResidualTol = 1e-10
+	}
+	if opts.StepTol <= 0.0 {
+		opts.StepTol = 1e-12
+	}
+	if opts.MinDamping <= 0.0 {
+		opts.MinDamping = 1e-4
+	}
+	return opts
+}
+
+// Solve finds the unknowns of the system that zero every residual with Newton-Raphson.
+// The initial guess binds every unknown and the parameters of the system, if any.
+// The Newton step comes from the symbolic Jacobian and is damped by a backtracking line search.
+func Solve(system *EquationSystem, initialGuess Env, opts *SolverOptions) (*SolverResult, error) {
+	o := opts.withDefaults()
+	vars := system.Variables()
+	residuals := system.Residuals()
+	if len(vars) != len(residuals) {
+		return nil, fmt.Errorf("%w: %d equations, %d unknowns", ErrNotSquare, len(residuals), len(vars))
+	}
+	jac := CreateJacobian(residuals, vars)
+
+	result := &SolverResult{Solution: make(Env, len(initialGuess))}
+	for name, value := range initialGuess {
+		result.Solution[name] = value
+	}
+	n := len(vars)
+	f := make([]float64, n)
+	trial := make([]float64, n)
+	step := make([]float64, n)
+	x := make([]float64, n)
+	j := make([][]float64, n)
+	for i := range j {
+		j[i] = make([]float64, n)
+	}
+
+	env := result.Solution
+	if err := evaluateInto(residuals, env, f); err != nil {
+		return nil, err
+	}
+	result.Residual = norm(f)
+	for result.Iterations < o.MaxIterations {
+		if result.Residual <= o.ResidualTol {
+			result.Converged = true
+			return result, nil
+		}
+
+		// Newton step: J dx = -F
+		if err := jac.EvaluateInto(env, j); err != nil {
+			return result, err
+		}
+		for i := range f {
+			step[i] = -f[i]
+		}
+		pivots, err := luFactorize(j)
+		if err != nil {
+			return result, err
+		}
+		luSolve(j, pivots, step)
+		for i, v := range vars {
+			x[i] = env[v.name]
+		}
+
+		// Backtrack until the residual norm decreases enough
+		damping := 1.0
+		trialNorm := math.Inf(1)
+		for damping >= o.MinDamping {
+			for i, v := range vars {
+				env[v.name] = x[i] + damping*step[i]
+			}
+			if err := evaluateInto(residuals, env, trial); err == nil {
+				trialNorm = norm(trial)
+				if trialNorm <= (1.0-1e-4*damping)*result.Residual {
+					break
+				}
+			}
+			damping /= 2.0
+		}
+		if damping < o.MinDamping {
+			// The line search failed, restore the last point
+			for i, v := range vars {
+				env[v.name] = x[i]
+			}
+			return result, nil
+		}
+
+		stepNorm := damping * norm(step)
+		result.Trace = append(result.Trace, SolverIteration{
+			Residual: result.Residual,
+			Step:     stepNorm,
+			Damping:  damping,
+		})
+		result.Iterations++
+		copy(f, trial)
+		result.Residual = trialNorm
+		if stepNorm <= o.StepTol*(1.0+norm(x)) {
+			// The iterates stalled, which is only a solution if the residual vanished too
+			result.Converged = result.Residual <= o.ResidualTol
+			return result, nil
+		}
+	}
+	result.Converged = result.Residual <= o.ResidualTol
+	return result, nil
+}
+
+// SolveScalar finds a root of f in v starting from the given guess
+func SolveScalar(f Evaluatable, v *Variable, guess float64, opts *SolverOptions) (*SolverResult, error) {
+	system := CreateEquationSystem()
+	system.GetVariable(v.name)
+	system.AddEquation(CreateEquation(f, GetConstant(ConstantZero)))
+	return Solve(system, Env{v.name: guess}, opts)
+}
+
+// Evaluates every expression with the given Env into dst
+func evaluateInto(exprs []Evaluatable, env Env, dst []float64) error {
+	for i, expr := range exprs {
+		value, err := expr.EvaluateWith(env)
+		if err != nil {
+			return err
+		}
+		dst[i] = value
+	}
+	return nil
+}
+
+// Returns the euclidean norm of v
+func norm(v []float64) float64 {
+	sum := 0.0
+	for _, value := range v {
+		sum += value * value
+	}
+	return math.Sqrt(sum)
+}
+
+// Factorizes the square matrix a in place into L and U with partial pivoting.
+// Returns the row permutation, pivots[k] is the row swapped with k.
+func luFactorize(a [][]float64) ([]int, error) {
+	n := len(a)
+	pivots := make([]int, n)
+	for k := 0; k < n; k++ {
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a[i][k]) > math.Abs(a[pivot][k]) {
+				pivot = i
+			}
+		}
+		if a[pivot][k] == 0.0 || math.IsNaN(a[pivot][k]) {
+			return nil, fmt.Errorf("%w: zero pivot in column %d", ErrSingularMatrix, k)
+		}
+		pivots[k] = pivot
+		a[k], a[pivot] = a[pivot], a[k]
+		for i := k + 1; i < n; i++ {
+			a[i][k] /= a[k][k]
+			for j := k + 1; j < n; j++ {
+				a[i][j] -= a[i][k] * a[k][j]
+			}
+		}
+	}
+	return pivots, nil
+}
+
+// Solves a x = b in place of b given the factorization of luFactorize
+func luSolve(lu [][]float64, pivots []int, b []float64) {
+	n := len(lu)
+	for k := 0; k < n; k++ {
+		b[k], b[pivots[k]] = b[pivots[k]], b[k]
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			b[i] -= lu[i][j] * b[j]
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			b[i] -= lu[i][j] * b[j]
+		}
+		b[i] /= lu[i][i]
+	}
+}
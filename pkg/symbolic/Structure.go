@@ -0,0 +1,283 @@
+package symbolic
+
+import (
+	"sort"
+)
+
+// A Block groups Equations with the unknowns they determine.
+// In the blocks of a decomposition Equations[k] is matched to Variables[k].
+type Block struct {
+	Equations []*Equation
+	Variables []*Variable
+}
+
+// The structural analysis of an EquationSystem
+type StructuralAnalysis struct {
+	Incidence       [][]bool // Incidence[i][j] is true if equation i is function of variable j
+	Matching        []int    // Matching[i] is the variable matched to equation i, -1 if unmatched
+	OverDetermined  Block    // Equations that outnumber the unknowns they contain
+	UnderDetermined Block    // Unknowns that outnumber the equations containing them
+	Blocks          []Block  // Block lower triangular decomposition of the rest, in solving order
+}
+
+// Returns true if part of the system is over or under determined
+func (a *StructuralAnalysis) IsSingular() bool {
+	return len(a.OverDetermined.Equations) > 0 || len(a.UnderDetermined.Variables) > 0
+}
+
+// Returns the incidence matrix: true where equation i is function of variable j
+func (s *EquationSystem) Incidence() [][]bool {
+	incidence := make([][]bool, len(s.equations))
+	for i, eq := range s.equations {
+		incidence[i] = make([]bool, len(s.variables))
+		for j, v := range s.variables {
+			incidence[i][j] = eq.FunctionOf(v)
+		}
+	}
+	return incidence
+}
+
+// Analyze matches equations to unknowns, isolates the structurally singular parts
+// and splits the rest in blocks that can be solved one after the other
+func (s *EquationSystem) Analyze() *StructuralAnalysis {
+	g := newBipartite(s.Incidence(), len(s.variables))
+	g.maximumMatching()
+
+	overEqs, overVars := g.overDetermined()
+	underEqs, underVars := g.underDetermined()
+
+	analysis := &StructuralAnalysis{
+		Incidence:       g.incidence,
+		Matching:        g.eqMatch,
+		OverDetermined:  s.block(overEqs, overVars),
+		UnderDetermined: s.block(underEqs, underVars),
+	}
+
+	singular := make([]bool, len(s.equations))
+	for _, i := range overEqs {
+		singular[i] = true
+	}
+	for _, i := range underEqs {
+		singular[i] = true
+	}
+	for _, component := range g.components(singular) {
+		vars := make([]int, len(component))
+		for k, i := range component {
+			vars[k] = g.eqMatch[i]
+		}
+		analysis.Blocks = append(analysis.Blocks, s.block(component, vars))
+	}
+	return analysis
+}
+
+// Returns the Block with the equations and variables at the given indices
+func (s *EquationSystem) block(eqs, vars []int) Block {
+	b := Block{
+		Equations: make([]*Equation, len(eqs)),
+		Variables: make([]*Variable, len(vars)),
+	}
+	for k, i := range eqs {
+		b.Equations[k] = s.equations[i]
+	}
+	for k, j := range vars {
+		b.Variables[k] = s.variables[j]
+	}
+	return b
+}
+
+// A bipartite graph of equations and variables along with a matching
+type bipartite struct {
+	incidence [][]bool
+	eqVars    [][]int // Variables of each equation
+	varEqs    [][]int // Equations of each variable
+	eqMatch   []int
+	varMatch  []int
+}
+
+func newBipartite(incidence [][]bool, nVars int) *bipartite {
+	g := &bipartite{
+		incidence: incidence,
+		eqVars:    make([][]int, len(incidence)),
+		varEqs:    make([][]int, nVars),
+		eqMatch:   make([]int, len(incidence)),
+		varMatch:  make([]int, nVars),
+	}
+	for i, row := range incidence {
+		g.eqMatch[i] = -1
+		for j, incident := range row {
+			if incident {
+				g.eqVars[i] = append(g.eqVars[i], j)
+				g.varEqs[j] = append(g.varEqs[j], i)
+			}
+		}
+	}
+	for j := range g.varMatch {
+		g.varMatch[j] = -1
+	}
+	return g
+}
+
+// Finds a maximum matching with augmenting paths
+func (g *bipartite) maximumMatching() {
+	for i := range g.eqVars {
+		visited := make([]bool, len(g.varMatch))
+		g.augment(i, visited)
+	}
+}
+
+// Looks for an augmenting path starting at equation i, true if one was found
+func (g *bipartite) augment(i int, visited []bool) bool {
+	for _, j := range g.eqVars[i] {
+		if visited[j] {
+			continue
+		}
+		visited[j] = true
+		if g.varMatch[j] < 0 || g.augment(g.varMatch[j], visited) {
+			g.eqMatch[i] = j
+			g.varMatch[j] = i
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the equations and variables reachable through alternating paths from unmatched equations
+func (g *bipartite) overDetermined() ([]int, []int) {
+	eqSeen := make([]bool, len(g.eqMatch))
+	varSeen := make([]bool, len(g.varMatch))
+	var queue []int
+	for i, j := range g.eqMatch {
+		if j < 0 {
+			eqSeen[i] = true
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, j := range g.eqVars[i] {
+			if varSeen[j] {
+				continue
+			}
+			varSeen[j] = true
+			// A maximum matching leaves no unmatched variable here
+			if next := g.varMatch[j]; next >= 0 && !eqSeen[next] {
+				eqSeen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return indices(eqSeen), indices(varSeen)
+}
+
+// Returns the equations and variables reachable through alternating paths from unmatched variables
+func (g *bipartite) underDetermined() ([]int, []int) {
+	eqSeen := make([]bool, len(g.eqMatch))
+	varSeen := make([]bool, len(g.varMatch))
+	var queue []int
+	for j, i := range g.varMatch {
+		if i < 0 {
+			varSeen[j] = true
+			queue = append(queue, j)
+		}
+	}
+	for len(queue) > 0 {
+		j := queue[0]
+		queue = queue[1:]
+		for _, i := range g.varEqs[j] {
+			if eqSeen[i] {
+				continue
+			}
+			eqSeen[i] = true
+			// A maximum matching leaves no unmatched equation here
+			if next := g.eqMatch[i]; next >= 0 && !varSeen[next] {
+				varSeen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return indices(eqSeen), indices(varSeen)
+}
+
+// Returns the strongly connected components of the matched equations that are not excluded.
+// Equation i depends on equation k if i contains the variable matched to k.
+// Tarjan's algorithm emits every component after the ones it depends on.
+func (g *bipartite) components(excluded []bool) [][]int {
+	t := tarjan{
+		g:        g,
+		excluded: excluded,
+		index:    make([]int, len(g.eqMatch)),
+		low:      make([]int, len(g.eqMatch)),
+		onStack:  make([]bool, len(g.eqMatch)),
+	}
+	for i := range t.index {
+		t.index[i] = -1
+	}
+	for i := range g.eqMatch {
+		if !excluded[i] && t.index[i] < 0 {
+			t.visit(i)
+		}
+	}
+	return t.components
+}
+
+// The state of Tarjan's strongly connected components algorithm
+type tarjan struct {
+	g          *bipartite
+	excluded   []bool
+	counter    int
+	index      []int
+	low        []int
+	stack      []int
+	onStack    []bool
+	components [][]int
+}
+
+func (t *tarjan) visit(i int) {
+	t.index[i] = t.counter
+	t.low[i] = t.counter
+	t.counter++
+	t.stack = append(t.stack, i)
+	t.onStack[i] = true
+
+	for _, j := range t.g.eqVars[i] {
+		k := t.g.varMatch[j]
+		if k < 0 || k == i || t.excluded[k] {
+			continue
+		}
+		if t.index[k] < 0 {
+			t.visit(k)
+			if t.low[k] < t.low[i] {
+				t.low[i] = t.low[k]
+			}
+		} else if t.onStack[k] && t.index[k] < t.low[i] {
+			t.low[i] = t.index[k]
+		}
+	}
+
+	if t.low[i] == t.index[i] {
+		var component []int
+		for {
+			top := t.stack[len(t.stack)-1]
+			t.stack = t.stack[:len(t.stack)-1]
+			t.onStack[top] = false
+			component = append(component, top)
+			if top == i {
+				break
+			}
+		}
+		sort.Ints(component)
+		t.components = append(t.components, component)
+	}
+}
+
+// Returns the indices set to true
+func indices(set []bool) []int {
+	var result []int
+	for i, in := range set {
+		if in {
+			result = append(result, i)
+		}
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package symbolic
+
+// Substitute returns a copy of expr with every Variable of the map replaced by its expression.
+// Replacements are simultaneous: the expressions substituted are not substituted again.
+// The original expression is left untouched.
+func Substitute(expr Evaluatable, values map[*Variable]Evaluatable) Evaluatable {
+	byName := make(map[string]Evaluatable, len(values))
+	for v, value := range values {
+		byName[v.name] = value
+	}
+	return substitute(expr, byName)
+}
+
+// PartialEval replaces the Variables bound in env with their values and trims,
+// leaving the other Variables symbolic.
+// Returns the evaluation error of a part that becomes constant and is not defined, like 1 / (x - 2) with x = 2.
+func PartialEval(expr Evaluatable, env Env) (Evaluatable, error) {
+	values := make(map[string]Evaluatable, len(env))
+	for name, value := range env {
+		values[name] = GetConstantValue(value)
+	}
+	bound := substitute(expr, values)
+	if err := constantError(bound); err != nil {
+		return nil, err
+	}
+	return bound.Trim(), nil
+}
+
+// Returns a copy of e with the Variables replaced by their entry of values, by name
+func substitute(e Evaluatable, values map[string]Evaluatable) Evaluatable {
+	switch n := e.(type) {
+	case *Variable:
+		if value, ok := values[n.name]; ok {
+			return value
+		}
+		return n
+	case operator:
+		left, right := n.operands()
+		left = substitute(left, values)
+		if right != nil {
+			right = substitute(right, values)
+		}
+		return n.rebuild(left, right)
+	default:
+		return e
+	}
+}
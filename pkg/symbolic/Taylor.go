@@ -0,0 +1,128 @@
+package symbolic
+
+import (
+	"fmt"
+	"math"
+)
+
+// A TaylorSeries is a truncated expansion along with an estimate of its error
+type TaylorSeries struct {
+	Polynomial Evaluatable
+	Remainder  Evaluatable // Terms of the first neglected order, the leading part of the error, nil if undefined
+}
+
+// Taylor returns the expansion of expr around v = point up to the given order:
+// the sum of f^(k)(point) / k! (v - point)^k for k from 0 to order, trimmed.
+// Returns an error if a derivative is not defined at the point. Panics if order is negative.
+func Taylor(expr Evaluatable, v *Variable, point Evaluatable, order int) (Evaluatable, error) {
+	series, err := ExpandTaylor(expr, []*Variable{v}, []Evaluatable{point}, order)
+	if err != nil {
+		return nil, err
+	}
+	return series.Polynomial, nil
+}
+
+// ExpandTaylor expands expr around vars[i] = point[i] up to the given total order,
+// summing d^a f(point) / a! (vars - point)^a over the multi-indices a of the orders up to order.
+// Returns an error if a derivative up to order is not defined at the point, such as those of sqrt(x) at 0.
+// The Remainder is nil when only the derivatives of order+1 are not defined there.
+// Panics if order is negative or point does not have a value for every Variable.
+func ExpandTaylor(expr Evaluatable, vars []*Variable, point []Evaluatable, order int) (*TaylorSeries, error) {
+	if order < 0 {
+		panic("ExpandTaylor: negative order")
+	}
+	if len(point) != len(vars) {
+		panic("ExpandTaylor: point and vars have different lengths")
+	}
+	t := taylor{
+		derivatives: CreateDerivatives(expr),
+		vars:        vars,
+		at:          make(map[string]Evaluatable, len(vars)),
+		offsets:     make([]Evaluatable, len(vars)),
+	}
+	for i, v := range vars {
+		t.at[v.name] = point[i]
+		t.offsets[i] = NodeSub(v, point[i]).Trim()
+	}
+
+	series := &TaylorSeries{Polynomial: GetConstant(ConstantZero)}
+	for k := 0; k <= order; k++ {
+		terms, err := t.terms(k)
+		if err != nil {
+			return nil, err
+		}
+		series.Polynomial = NodeAdd(series.Polynomial, terms)
+	}
+	series.Polynomial = series.Polynomial.Trim()
+	if remainder, err := t.terms(order + 1); err == nil {
+		series.Remainder = remainder.Trim()
+	}
+	return series, nil
+}
+
+// The state of a Taylor expansion
+type taylor struct {
+	derivatives *Derivatives
+	vars        []*Variable
+	at          map[string]Evaluatable // The point, by variable name
+	offsets     []Evaluatable          // vars[i] - point[i]
+}
+
+// Returns the sum of the terms of total order k
+func (t *taylor) terms(k int) (Evaluatable, error) {
+	sum := Evaluatable(GetConstant(ConstantZero))
+	index := make([]int, len(t.vars))
+	var err error
+	var visit func(i, left int)
+	visit = func(i, left int) {
+		if i == len(t.vars) {
+			if left == 0 && err == nil {
+				var term Evaluatable
+				if term, err = t.term(index); err == nil {
+					sum = NodeAdd(sum, term)
+				}
+			}
+			return
+		}
+		for count := left; count >= 0; count-- {
+			index[i] = count
+			visit(i+1, left-count)
+		}
+		index[i] = 0
+	}
+	visit(0, k)
+	if err != nil {
+		return nil, fmt.Errorf("derivative of order %d is not defined at the point: %w", k, err)
+	}
+	return sum, nil
+}
+
+// Returns the term d^index f(point) / index! (vars - point)^index
+func (t *taylor) term(index []int) (Evaluatable, error) {
+	var along []*Variable
+	factorial := 1.0
+	for i, count := range index {
+		for c := 1; c <= count; c++ {
+			along = append(along, t.vars[i])
+			factorial *= float64(c)
+		}
+	}
+	coefficient := substitute(t.derivatives.Partial(along...), t.at)
+	if err := constantError(coefficient); err != nil {
+		return nil, err
+	}
+	coefficient = coefficient.Trim()
+	value, ok := constantValue(coefficient)
+	if ok && (math.IsInf(value, 0) || math.IsNaN(value)) {
+		return nil, fmt.Errorf("coefficient evaluates to %g", value)
+	} else if ok && value == 0.0 {
+		return GetConstant(ConstantZero), nil
+	}
+	term := NodeDivide(coefficient, GetConstantValue(factorial))
+	for i, count := range index {
+		if count > 0 {
+			term = NodeMultiply(term, NodePow(t.offsets[i], GetConstantValue(float64(count))))
+		}
+	}
+	return term, nil
+}
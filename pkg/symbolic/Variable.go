@@ -15,6 +15,15 @@ func (v *Variable) SetValue(newValue float64) {
 	v.value = newValue
 }
 
+// Reads the value bound to the name of this Variable instead of its own value
+func (v *Variable) EvaluateWith(env Env) (float64, error) {
+	value, ok := env[v.name]
+	if !ok {
+		return 0.0, &EvaluationError{Expr: v.name, Err: ErrUnboundVariable}
+	}
+	return value, nil
+}
+
 // Returns true if this variable matches the one being checked
 func (this *Variable) FunctionOf(v *Variable) bool {
 	return this.name == (*v).name
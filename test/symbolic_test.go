@@ -1,8 +1,20 @@
 package test
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	symb "symbolic-algebra/pkg/symbolic"
+	"sync"
 	"testing"
 )
 
@@ -245,8 +257,8 @@ func TestDiffWithSub(t *testing.T) {
 		x,
 	)
 	expr := y.Diff(x).String()
-	if expr != "(-1 * 1)" {
-		t.Error("Expected (-1 * 1) for derivative, got", expr)
+	if expr != "(-1)" {
+		t.Error("Expected (-1) for derivative, got", expr)
 	}
 }
 
@@ -271,8 +283,8 @@ func TestDiffWithDivide(t *testing.T) {
 		x,
 	)
 	expr := y.Diff(x).String()
-	if expr != "(((-1 * 1) * 1) / (x * x))" {
-		t.Error("Expected (((-1 * 1) * 1) / (x * x)) for derivative, got", expr)
+	if expr != "(((-1) * 1) / (x * x))" {
+		t.Error("Expected (((-1) * 1) / (x * x)) for derivative, got", expr)
 	}
 }
 
@@ -450,8 +462,8 @@ func TestDiffWithCos(t *testing.T) {
 	)
 
 	expr := cos.Diff(x).String()
-	if expr != "((-1 * sin((2 * x))) * (2 * 1))" {
-		t.Error("Expected ((-1 * sin((2 * x))) * (2 * 1)), got", expr)
+	if expr != "((-sin((2 * x))) * (2 * 1))" {
+		t.Error("Expected ((-sin((2 * x))) * (2 * 1)), got", expr)
 	}
 }
 
@@ -556,3 +568,2235 @@ func TestTrimWithDivide(t *testing.T) {
 	divZero := symb.NodeDivide(x, zero)
 	divZero.Trim()
 }
+
+func TestTrimWithPow(t *testing.T) {
+	x := symb.CreateVariable("x")
+	zero := symb.GetConstant(symb.ConstantZero)
+	one := symb.GetConstant(symb.ConstantOne)
+
+	// x^0 = 1, x^1 = x, 1^x = 1:
+	cases := map[symb.Evaluatable]string{
+		symb.NodePow(x, zero):                        "1",
+		symb.NodePow(x, one):                         "x",
+		symb.NodePow(one, x):                         "1",
+		symb.NodePow(symb.NodeAdd(x, zero), x):       "(x ^ x)",
+		symb.NodePow(x, symb.NodeMultiply(one, x)):   "(x ^ x)",
+		symb.NodePow(symb.NodeMultiply(x, one), one): "x",
+	}
+	for expr, want := range cases {
+		if got := expr.Trim().String(); got != want {
+			t.Error("Trimming", expr, "expected", want, "got", got)
+		}
+	}
+
+	// Derivatives of pow can be trimmed:
+	two := symb.GetConstantValue(2.0)
+	expr := symb.NodePow(x, two).Diff(x).Trim().String()
+	if expr != "(2 * x)" {
+		t.Error("Expected (2 * x), got", expr)
+	}
+
+	// Undetermined 0^0 panic:
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected trim with 0^0 detected to panic.")
+		}
+	}()
+	symb.NodePow(zero, zero).Trim()
+}
+
+func TestTrimWithLn(t *testing.T) {
+	x := symb.CreateVariable("x")
+	one := symb.GetConstant(symb.ConstantOne)
+	e := symb.GetConstant(symb.ConstantE)
+
+	cases := map[symb.Evaluatable]string{
+		symb.NodeLn(one):                               "0",
+		symb.NodeLn(e):                                 "1",
+		symb.NodeLn(symb.NodePow(e, x)):                "x",
+		symb.NodeLn(symb.NodeMultiply(x, one)):         "ln(x)",
+		symb.NodeLn(symb.NodePow(x, one)):              "ln(x)",
+		symb.NodeLn(symb.GetConstant(symb.ConstantPi)): "ln(pi)",
+	}
+	for expr, want := range cases {
+		if got := expr.Trim().String(); got != want {
+			t.Error("Trimming", expr, "expected", want, "got", got)
+		}
+	}
+}
+
+func TestTrimWithSin(t *testing.T) {
+	x := symb.CreateVariable("x")
+	zero := symb.GetConstant(symb.ConstantZero)
+
+	cases := map[symb.Evaluatable]string{
+		symb.NodeSin(zero):                       "0",
+		symb.NodeSin(symb.NodeMultiply(x, zero)): "0",
+		symb.NodeSin(symb.NodeAdd(x, zero)):      "sin(x)",
+	}
+	for expr, want := range cases {
+		if got := expr.Trim().String(); got != want {
+			t.Error("Trimming", expr, "expected", want, "got", got)
+		}
+	}
+}
+
+func TestTrimWithCos(t *testing.T) {
+	x := symb.CreateVariable("x")
+	zero := symb.GetConstant(symb.ConstantZero)
+
+	cases := map[symb.Evaluatable]string{
+		symb.NodeCos(zero):                       "1",
+		symb.NodeCos(symb.NodeMultiply(x, zero)): "1",
+		symb.NodeCos(symb.NodeAdd(x, zero)):      "cos(x)",
+	}
+	for expr, want := range cases {
+		if got := expr.Trim().String(); got != want {
+			t.Error("Trimming", expr, "expected", want, "got", got)
+		}
+	}
+
+	// Derivatives of cos can be trimmed:
+	two := symb.GetConstantValue(2.0)
+	expr := symb.NodeCos(symb.NodeMultiply(two, x)).Diff(x).Trim().String()
+	if expr != "((-sin((2 * x))) * 2)" {
+		t.Error("Expected ((-sin((2 * x))) * 2), got", expr)
+	}
+}
+
+func TestTrimWithConstantFolding(t *testing.T) {
+	cases := map[string]string{
+		"(2 + 3) * x":        "(5 * x)",
+		"x * (2 - 3 * 4)":    "(x * -10)",
+		"x / (1 / 4)":        "(x / 0.25)",
+		"2 ^ 10 + x":         "(1024 + x)",
+		"x ^ (4 / 2)":        "(x ^ 2)",
+		"ln(1 + 1) * x":      "(0.6931471805599453 * x)",
+		"sin(0 * 2) + x":     "x",
+		"cos(3 - 3) + x":     "(1 + x)",
+		"2 * pi * x":         "((2 * pi) * x)",
+		"(-8) ^ (1 / 3) + x": "((-8 ^ 0.3333333333333333) + x)",
+		"0 - x":              "(-x)",
+		"ln(0 - 1) * x":      "(ln(-1) * x)",
+	}
+	for input, want := range cases {
+		expr, err := symb.Parse(input)
+		if err != nil {
+			t.Error("Unexpected error parsing", input, err)
+			continue
+		}
+		if got := expr.Trim().String(); got != want {
+			t.Error("Trimming", input, "expected", want, "got", got)
+		}
+	}
+
+	// Derivatives shrink to the expected form:
+	x := symb.CreateVariable("x")
+	x3 := symb.NodePow(x, symb.GetConstantValue(3.0))
+	expr := x3.Diff(x).Trim().String()
+	if expr != "(3 * (x ^ 2))" {
+		t.Error("Expected (3 * (x ^ 2)), got", expr)
+	}
+}
+
+func TestFold(t *testing.T) {
+	cases := map[string]string{
+		"2 * pi * x":    "(6.283185307179586 * x)",
+		"ln(e) * x":     "x",
+		"x ^ (e - e)":   "1",
+		"sin(pi) + x":   "(1.2246467991473515e-16 + x)",
+		"x * y + 2 * 3": "((x * y) + 6)",
+		"ln(0 - pi)":    "ln(-3.141592653589793)",
+	}
+	for input, want := range cases {
+		expr, err := symb.Parse(input)
+		if err != nil {
+			t.Error("Unexpected error parsing", input, err)
+			continue
+		}
+		if got := symb.Fold(expr).String(); got != want {
+			t.Error("Folding", input, "expected", want, "got", got)
+		}
+	}
+}
+
+func TestFoldConcurrent(t *testing.T) {
+	x := symb.CreateVariable("x")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(offset int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				value := float64(offset*1000 + j + 2)
+				c := symb.GetConstantValue(value)
+				expr := symb.NodeMultiply(symb.NodeAdd(c, c), symb.NodeLog(symb.NodeSqrt(c), x))
+				want := fmt.Sprintf("(%g * log(%g, x))", 2*value, math.Sqrt(value))
+				if got := expr.Trim().String(); got != want {
+					t.Error("Trimming", expr, "expected", want, "got", got)
+					return
+				}
+				if got := symb.Fold(symb.NodeDivide(x, symb.NodeAbs(c))).String(); got != fmt.Sprintf("(x / %g)", value) {
+					t.Error("Folding", x, "/", value, "got", got)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestParsePrecedence(t *testing.T) {
+	cases := map[string]string{
+		"1 + 2 * x":     "(1 + (2 * x))",
+		"(1 + 2) * x":   "((1 + 2) * x)",
+		"x - y - 1":     "((x - y) - 1)",
+		"x / y * 2":     "((x / y) * 2)",
+		"x ^ 2 ^ 3":     "(x ^ (2 ^ 3))",
+		"2 * x ^ 2":     "(2 * (x ^ 2))",
+		"-x ^ 2":        "(-(x ^ 2))",
+		"x ^ -2":        "(x ^ (-2))",
+		"--x":           "(-(-x))",
+		"sin(x) ^ 2":    "(sin(x) ^ 2)",
+		"ln(e * pi)":    "ln((e * pi))",
+		"cos(2.5e-1*x)": "cos((0.25 * x))",
+	}
+	for input, want := range cases {
+		expr, err := symb.Parse(input)
+		if err != nil {
+			t.Error("Unexpected error parsing", input, err)
+			continue
+		}
+		if got := expr.String(); got != want {
+			t.Error("Parsing", input, "expected", want, "got", got)
+		}
+	}
+}
+
+func TestParseEvaluate(t *testing.T) {
+	x := symb.CreateVariable("x")
+	expr, err := symb.ParseWith("2 * x ^ 3 - x / 4 + sin(pi / 2)", x)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	x.SetValue(2.0)
+	got := expr.Evaluate()
+	if math.Abs(got-16.5) > 1e-10 {
+		t.Error("Expected 16.5, got", got)
+	}
+
+	// The same name refers to the same variable
+	expr, err = symb.Parse("y * y")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	y := symb.CreateVariable("y")
+	if !expr.FunctionOf(y) {
+		t.Error("Expected", expr, "to be function of y")
+	}
+}
+
+func TestParseConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(offset int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				value := float64(offset*1000 + j)
+				input := fmt.Sprintf("x * %g + %g", value, value+0.5)
+				expr, err := symb.Parse(input)
+				if err != nil {
+					t.Error("Unexpected error:", err)
+					return
+				}
+				if got, _ := expr.EvaluateWith(symb.Env{"x": 1.0}); got != 2*value+0.5 {
+					t.Error("Parsing", input, "expected", 2*value+0.5, "got", got)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Numeric literals do not become named constants
+	if _, err := symb.LookupConstant("1000"); !errors.Is(err, symb.ErrUnknownConstant) {
+		t.Error("Expected ErrUnknownConstant, got", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		input  string
+		offset int
+		token  string
+	}{
+		{"1 +", 3, ""},
+		{"(x + 1", 6, ""},
+		{"x + * 2", 4, "*"},
+		{"2 x", 2, "x"},
+		{"foo(x)", 0, "foo"},
+		{"sin + 1", 0, "sin"},
+		{"x $ 1", 2, "$"},
+		{"x)", 1, ")"},
+	}
+	for _, c := range cases {
+		_, err := symb.Parse(c.input)
+		perr, ok := err.(*symb.ParseError)
+		if !ok {
+			t.Error("Expected a ParseError for", c.input, "got", err)
+			continue
+		}
+		if perr.Offset != c.offset || perr.Token != c.token {
+			t.Error("Parsing", c.input, "expected error at", c.offset, c.token, "got", perr)
+		}
+	}
+}
+
+func TestEvaluateErr(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, err := symb.ParseWith("ln(x) + x / y - x ^ y", x, y)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// Same result as Evaluate:
+	x.SetValue(2.0)
+	y.SetValue(4.0)
+	got, err := expr.EvaluateErr()
+	if err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	if got != expr.Evaluate() {
+		t.Error("Expected", expr.Evaluate(), "got", got)
+	}
+
+	// Division by zero:
+	y.SetValue(0.0)
+	_, err = expr.EvaluateErr()
+	if !errors.Is(err, symb.ErrDivisionByZero) {
+		t.Error("Expected ErrDivisionByZero, got", err)
+	}
+	var evalErr *symb.EvaluationError
+	if !errors.As(err, &evalErr) || evalErr.Expr != "(x / y)" {
+		t.Error("Expected the error to name (x / y), got", err)
+	}
+
+	// Domain error of ln:
+	x.SetValue(-1.0)
+	y.SetValue(1.0)
+	_, err = expr.EvaluateErr()
+	var domainErr *symb.ErrDomain
+	if !errors.As(err, &domainErr) {
+		t.Fatal("Expected ErrDomain, got", err)
+	}
+	if domainErr.Func != "ln" || domainErr.Arg != -1.0 {
+		t.Error("Expected ln domain error for -1, got", domainErr)
+	}
+	if !errors.As(err, &evalErr) || evalErr.Expr != "ln(x)" {
+		t.Error("Expected the error to name ln(x), got", err)
+	}
+
+	// Undetermined 0^0:
+	x.SetValue(0.0)
+	y.SetValue(0.0)
+	_, err = symb.NodePow(x, y).EvaluateErr()
+	if !errors.As(err, &domainErr) || domainErr.Func != "pow" {
+		t.Error("Expected pow domain error, got", err)
+	}
+}
+
+func TestLookupConstant(t *testing.T) {
+	pi, err := symb.LookupConstant(symb.ConstantPi)
+	if err != nil || pi.Evaluate() != math.Pi {
+		t.Error("Expected pi, got", pi, err)
+	}
+	_, err = symb.LookupConstant("tau")
+	if !errors.Is(err, symb.ErrUnknownConstant) {
+		t.Error("Expected ErrUnknownConstant, got", err)
+	}
+}
+
+func TestEvaluateWith(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, err := symb.ParseWith("x ^ 2 + y / 2", x, y)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// The values stored in the variables are ignored:
+	x.SetValue(100.0)
+	got, err := expr.EvaluateWith(symb.Env{"x": 3.0, "y": 4.0})
+	if err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	if got != 11.0 {
+		t.Error("Expected 11.0, got", got)
+	}
+
+	// Unbound variable:
+	_, err = expr.EvaluateWith(symb.Env{"x": 3.0})
+	if !errors.Is(err, symb.ErrUnboundVariable) {
+		t.Error("Expected ErrUnboundVariable, got", err)
+	}
+	var evalErr *symb.EvaluationError
+	if !errors.As(err, &evalErr) || evalErr.Expr != "y" {
+		t.Error("Expected the error to name y, got", err)
+	}
+
+	// Domain errors are reported as with EvaluateErr:
+	_, err = symb.NodeDivide(x, y).EvaluateWith(symb.Env{"x": 1.0, "y": 0.0})
+	if !errors.Is(err, symb.ErrDivisionByZero) {
+		t.Error("Expected ErrDivisionByZero, got", err)
+	}
+}
+
+func TestEvaluateWithConcurrent(t *testing.T) {
+	expr, err := symb.Parse("sin(x) * x + 1")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(value float64) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				got, err := expr.EvaluateWith(symb.Env{"x": value})
+				want := math.Sin(value)*value + 1.0
+				if err != nil || got != want {
+					t.Error("Expected", want, "got", got, err)
+					return
+				}
+			}
+		}(float64(i))
+	}
+	wg.Wait()
+}
+
+func TestJacobian(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	z := symb.CreateVariable("z")
+	f1, _ := symb.ParseWith("x ^ 2 * y", x, y)
+	f2, _ := symb.ParseWith("sin(y) + 3", y)
+	vars := []*symb.Variable{x, y, z}
+
+	entries := symb.Jacobian([]symb.Evaluatable{f1, f2}, vars)
+	want := [][]string{
+		{"((2 * x) * y)", "(x ^ 2)", "0"},
+		{"0", "cos(y)", "0"},
+	}
+	for i := range want {
+		for j := range want[i] {
+			if got := entries[i][j].String(); got != want[i][j] {
+				t.Error("Entry", i, j, "expected", want[i][j], "got", got)
+			}
+		}
+	}
+
+	jac := symb.CreateJacobian([]symb.Evaluatable{f1, f2}, vars)
+	pattern := [][]bool{{true, true, false}, {false, true, false}}
+	for i := range pattern {
+		for j := range pattern[i] {
+			if jac.Pattern[i][j] != pattern[i][j] {
+				t.Error("Pattern", i, j, "expected", pattern[i][j])
+			}
+		}
+	}
+	if jac.NonZeros() != 3 {
+		t.Error("Expected 3 non zeros, got", jac.NonZeros())
+	}
+
+	env := symb.Env{"x": 2.0, "y": 0.0, "z": 1.0}
+	dense, err := jac.Evaluate(env)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	values := [][]float64{{0.0, 4.0, 0.0}, {0.0, 1.0, 0.0}}
+	for i := range values {
+		for j := range values[i] {
+			if dense[i][j] != values[i][j] {
+				t.Error("Value", i, j, "expected", values[i][j], "got", dense[i][j])
+			}
+		}
+	}
+
+	sparse, err := jac.EvaluateSparse(env)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if sparse.Rows != 2 || sparse.Cols != 3 || len(sparse.Values) != 3 {
+		t.Fatal("Wrong sparse matrix", sparse)
+	}
+	for k, v := range sparse.Values {
+		if v != values[sparse.RowIndex[k]][sparse.ColIndex[k]] {
+			t.Error("Sparse value", k, "does not match the dense one")
+		}
+	}
+
+	_, err = jac.Evaluate(symb.Env{"x": 2.0})
+	if !errors.Is(err, symb.ErrUnboundVariable) {
+		t.Error("Expected ErrUnboundVariable, got", err)
+	}
+}
+
+func TestEquation(t *testing.T) {
+	x := symb.CreateVariable("x")
+	eq := symb.CreateEquation(symb.NodeMultiply(x, x), symb.GetConstantValue(4.0))
+	if got := eq.String(); got != "(x * x) = 4" {
+		t.Error("Expected (x * x) = 4, got", got)
+	}
+	if got := eq.Residual().String(); got != "((x * x) - 4)" {
+		t.Error("Expected ((x * x) - 4), got", got)
+	}
+	if !eq.FunctionOf(x) {
+		t.Error("Expected", eq, "to be function of x")
+	}
+}
+
+func TestParseEquation(t *testing.T) {
+	system := symb.CreateEquationSystem()
+	x := system.GetVariable("x")
+	eq, err := system.ParseEquation("x + y = 2 * pi")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := eq.String(); got != "(x + y) = (2 * pi)" {
+		t.Error("Expected (x + y) = (2 * pi), got", got)
+	}
+	vars := system.Variables()
+	if len(vars) != 2 || vars[0] != x || vars[1].GetName() != "y" {
+		t.Error("Expected the pool to hold x and y, got", vars)
+	}
+	if system.GetVariable("y") != vars[1] {
+		t.Error("Expected GetVariable to return the pooled y")
+	}
+
+	// Errors report the offset in the whole input and leave the system untouched:
+	cases := []struct {
+		input  string
+		offset int
+	}{
+		{"x + z", 5},
+		{"z = 1 = 2", 6},
+		{"z = 1 +", 7},
+		{"z * = 1", 4},
+	}
+	for _, c := range cases {
+		_, err := system.ParseEquation(c.input)
+		perr, ok := err.(*symb.ParseError)
+		if !ok || perr.Offset != c.offset {
+			t.Error("Parsing", c.input, "expected error at", c.offset, "got", err)
+		}
+	}
+
+	// So do constant parts that can not be evaluated:
+	if _, err := system.ParseEquation("z = 1 / 0"); !errors.Is(err, symb.ErrDivisionByZero) {
+		t.Error("Expected ErrDivisionByZero, got", err)
+	}
+	var domainErr *symb.ErrDomain
+	if _, err := system.ParseEquation("z * 0 ^ 0 = 1"); !errors.As(err, &domainErr) {
+		t.Error("Expected an ErrDomain, got", err)
+	}
+	if len(system.Variables()) != 2 || len(system.Equations()) != 1 {
+		t.Error("Failed parses should not change the system")
+	}
+}
+
+func TestStructuralAnalysis(t *testing.T) {
+	system := symb.CreateEquationSystem()
+	for _, eq := range []string{"z * x = 2", "x + y = 3", "x - y = 1", "w = z + y"} {
+		if _, err := system.ParseEquation(eq); err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+	}
+	analysis := system.Analyze()
+	if analysis.IsSingular() {
+		t.Fatal("Expected a regular system")
+	}
+	// Variables are pooled in order z, x, y, w
+	incidence := [][]bool{
+		{true, true, false, false},
+		{false, true, true, false},
+		{false, true, true, false},
+		{true, false, true, true},
+	}
+	for i := range incidence {
+		for j := range incidence[i] {
+			if analysis.Incidence[i][j] != incidence[i][j] {
+				t.Error("Incidence", i, j, "expected", incidence[i][j])
+			}
+		}
+	}
+
+	// Blocks in solving order: {x, y}, then z, then w
+	want := [][]string{{"x", "y"}, {"z"}, {"w"}}
+	if len(analysis.Blocks) != len(want) {
+		t.Fatal("Expected", len(want), "blocks, got", len(analysis.Blocks))
+	}
+	for k, block := range analysis.Blocks {
+		names := make(map[string]bool)
+		for _, v := range block.Variables {
+			names[v.GetName()] = true
+		}
+		for _, name := range want[k] {
+			if !names[name] {
+				t.Error("Block", k, "expected to solve", name)
+			}
+		}
+		if len(block.Equations) != len(want[k]) || len(block.Variables) != len(want[k]) {
+			t.Error("Block", k, "has the wrong size")
+		}
+	}
+}
+
+func TestStructuralAnalysisSingular(t *testing.T) {
+	// x is determined by two equations:
+	system := symb.CreateEquationSystem()
+	for _, eq := range []string{"x + y = 1", "x = 2", "2 * x = 4"} {
+		system.ParseEquation(eq)
+	}
+	analysis := system.Analyze()
+	if !analysis.IsSingular() {
+		t.Fatal("Expected a singular system")
+	}
+	over := analysis.OverDetermined
+	if len(over.Equations) != 2 || len(over.Variables) != 1 || over.Variables[0].GetName() != "x" {
+		t.Error("Expected x over determined by two equations, got", over)
+	}
+	if len(analysis.Blocks) != 1 || analysis.Blocks[0].Variables[0].GetName() != "y" {
+		t.Error("Expected a single block solving y")
+	}
+
+	// z has no equation of its own:
+	system = symb.CreateEquationSystem()
+	for _, eq := range []string{"x + y + z = 1", "x = 2"} {
+		system.ParseEquation(eq)
+	}
+	analysis = system.Analyze()
+	under := analysis.UnderDetermined
+	if len(under.Equations) != 1 || len(under.Variables) != 2 {
+		t.Error("Expected one equation with two unknowns under determined, got", under)
+	}
+	if len(analysis.Blocks) != 1 || analysis.Blocks[0].Variables[0].GetName() != "x" {
+		t.Error("Expected a single block solving x")
+	}
+	for i, j := range analysis.Matching {
+		if j < 0 {
+			t.Error("Equation", i, "should be matched")
+		}
+	}
+}
+
+func TestSolve(t *testing.T) {
+	// Intersection of a circle and a line:
+	system := symb.CreateEquationSystem()
+	system.ParseEquation("x ^ 2 + y ^ 2 = 4")
+	system.ParseEquation("x = y")
+	result, err := symb.Solve(system, symb.Env{"x": 1.0, "y": 0.5}, nil)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !result.Converged {
+		t.Fatal("Expected convergence, got", result)
+	}
+	for _, name := range []string{"x", "y"} {
+		if math.Abs(result.Solution[name]-math.Sqrt2) > 1e-10 {
+			t.Error("Expected", name, "=", math.Sqrt2, "got", result.Solution[name])
+		}
+	}
+	if result.Residual > 1e-10 {
+		t.Error("Expected a residual below 1e-10, got", result.Residual)
+	}
+	if len(result.Trace) != result.Iterations {
+		t.Error("Expected one trace entry per iteration, got", len(result.Trace))
+	}
+	for k := 1; k < len(result.Trace); k++ {
+		if result.Trace[k].Residual >= result.Trace[k-1].Residual {
+			t.Error("Expected the residual to decrease at iteration", k)
+		}
+	}
+}
+
+func TestSolveWithParameters(t *testing.T) {
+	system := symb.CreateEquationSystem()
+	x := system.GetVariable("x")
+	a := symb.CreateVariable("a")
+	system.AddEquation(symb.CreateEquation(symb.NodeMultiply(a, x), symb.GetConstantValue(6.0)))
+	result, err := symb.Solve(system, symb.Env{"x": 0.0, "a": 2.0}, nil)
+	if err != nil || !result.Converged {
+		t.Fatal("Expected convergence, got", result, err)
+	}
+	if math.Abs(result.Solution["x"]-3.0) > 1e-10 {
+		t.Error("Expected x = 3, got", result.Solution["x"])
+	}
+}
+
+func TestSolveScalar(t *testing.T) {
+	x := symb.CreateVariable("x")
+	f, _ := symb.ParseWith("cos(x) - x", x)
+	result, err := symb.SolveScalar(f, x, 1.0, &symb.SolverOptions{ResidualTol: 1e-14})
+	if err != nil || !result.Converged {
+		t.Fatal("Expected convergence, got", result, err)
+	}
+	if math.Abs(result.Solution["x"]-0.7390851332151607) > 1e-12 {
+		t.Error("Expected x = 0.7390851332151607, got", result.Solution["x"])
+	}
+
+	// The line search keeps ln in its domain:
+	f, _ = symb.ParseWith("ln(x) - 1", x)
+	result, err = symb.SolveScalar(f, x, 10.0, nil)
+	if err != nil || !result.Converged {
+		t.Fatal("Expected convergence, got", result, err)
+	}
+	if math.Abs(result.Solution["x"]-math.E) > 1e-10 {
+		t.Error("Expected x = e, got", result.Solution["x"])
+	}
+	// A vanishing step is not a solution when the residual does not vanish:
+	// sin(1e12 x) + 2 has no root and steps of about 1e-12
+	f, _ = symb.ParseWith("sin(1000000000000 * x) + 2", x)
+	result, err = symb.SolveScalar(f, x, 0.3, nil)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if result.Converged || result.Residual < 1.0 {
+		t.Error("Expected no convergence with a residual of at least 1, got", result.Converged, result.Residual)
+	}
+}
+
+func TestSolveErrors(t *testing.T) {
+	system := symb.CreateEquationSystem()
+	system.ParseEquation("x + y = 1")
+	_, err := symb.Solve(system, symb.Env{"x": 0.0, "y": 0.0}, nil)
+	if !errors.Is(err, symb.ErrNotSquare) {
+		t.Error("Expected ErrNotSquare, got", err)
+	}
+
+	x := symb.CreateVariable("x")
+	f, _ := symb.ParseWith("x ^ 2 + 1", x)
+	_, err = symb.SolveScalar(f, x, 0.0, nil)
+	if !errors.Is(err, symb.ErrSingularMatrix) {
+		t.Error("Expected ErrSingularMatrix, got", err)
+	}
+
+	result, err := symb.SolveScalar(f, x, 1.0, &symb.SolverOptions{MaxIterations: 5})
+	if err != nil && !errors.Is(err, symb.ErrSingularMatrix) {
+		t.Error("Unexpected error:", err)
+	}
+	if result.Converged {
+		t.Error("x ^ 2 + 1 has no real root")
+	}
+}
+
+func TestLaTeX(t *testing.T) {
+	cases := map[string]string{
+		"x + y + z":         "x + y + z",
+		"x - (y - z)":       "x - \\left(y - z\\right)",
+		"(x - y) - z":       "x - y - z",
+		"2 * x":             "2x",
+		"x * y":             "x \\cdot y",
+		"2 * pi":            "2\\pi",
+		"2 * 3":             "2 \\cdot 3",
+		"(x + 1) * (x - 1)": "\\left(x + 1\\right) \\cdot \\left(x - 1\\right)",
+		"2 * (x + 1)":       "2\\left(x + 1\\right)",
+		"x ^ 2 * sin(x)":    "x^{2}\\sin\\left(x\\right)",
+		"(x + 1) / (x - 1)": "\\frac{x + 1}{x - 1}",
+		"(x / 2) ^ 2":       "\\left(\\frac{x}{2}\\right)^{2}",
+		"(x + 1) ^ (y + 1)": "\\left(x + 1\\right)^{y + 1}",
+		"x ^ y ^ z":         "x^{y^{z}}",
+		"e ^ x * ln(x)":     "e^{x}\\ln\\left(x\\right)",
+		"-x":                "-x",
+		"-(x + y)":          "-\\left(x + y\\right)",
+		"x + -y":            "x - y",
+		"x - -y":            "x - \\left(-y\\right)",
+		"x * -y":            "x \\cdot \\left(-y\\right)",
+		"-x * y":            "-x \\cdot y",
+		"cos(x) ^ 2":        "\\left(\\cos\\left(x\\right)\\right)^{2}",
+		"0.00001 * x":       "1 \\cdot 10^{-5} \\cdot x",
+		"2 * (1 / x)":       "2 \\cdot \\frac{1}{x}",
+		"y * (1 / x)":       "y\\frac{1}{x}",
+	}
+	for input, want := range cases {
+		expr, err := symb.Parse(input)
+		if err != nil {
+			t.Error("Unexpected error parsing", input, err)
+			continue
+		}
+		if got := symb.LaTeX(expr); got != want {
+			t.Error("LaTeX of", input, "expected", want, "got", got)
+		}
+	}
+
+	// Negative bases keep their parentheses:
+	x := symb.CreateVariable("x")
+	expr := symb.NodePow(symb.GetConstantValue(-2.0), x)
+	if got := symb.LaTeX(expr); got != "\\left(-2\\right)^{x}" {
+		t.Error("Expected \\left(-2\\right)^{x}, got", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	cases := map[string]string{
+		"((x + y) + z)":     "x + y + z",
+		"x + (y + z)":       "x + (y + z)",
+		"x - (y - z)":       "x - (y - z)",
+		"(x * y) / z":       "x * y / z",
+		"x / (y * z)":       "x / (y * z)",
+		"x * (y / z)":       "x * (y / z)",
+		"(x + 1) * 2":       "(x + 1) * 2",
+		"x ^ (y ^ z)":       "x ^ y ^ z",
+		"(x ^ y) ^ z":       "(x ^ y) ^ z",
+		"(-x) ^ 2":          "(-x) ^ 2",
+		"-(x ^ 2)":          "-x ^ 2",
+		"x ^ -2":            "x ^ (-2)",
+		"-x * y":            "-x * y",
+		"-(x * y)":          "-(x * y)",
+		"x + -y":            "x - y",
+		"x + -(y + z)":      "x - (y + z)",
+		"x - -y":            "x - (-y)",
+		"sin(x + y) * 2":    "sin(x + y) * 2",
+		"ln((x))":           "ln(x)",
+		"2 * pi * e ^ x":    "2 * pi * e ^ x",
+		"cos(x) ^ 2 / 2":    "cos(x) ^ 2 / 2",
+		"1 / (2 / x) + x/y": "1 / (2 / x) + x / y",
+	}
+	for input, want := range cases {
+		expr, err := symb.Parse(input)
+		if err != nil {
+			t.Error("Unexpected error parsing", input, err)
+			continue
+		}
+		if got := symb.Format(expr); got != want {
+			t.Error("Formatting", input, "expected", want, "got", got)
+		}
+	}
+
+	// Derivatives read back to the same values:
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, _ := symb.ParseWith("x ^ y * sin(x / y) - ln(x) / (1 - y)", x, y)
+	for _, v := range []*symb.Variable{x, y} {
+		diff := expr.Diff(v)
+		parsed, err := symb.ParseWith(symb.Format(diff), x, y)
+		if err != nil {
+			t.Fatal("Unexpected error parsing", symb.Format(diff), err)
+		}
+		env := symb.Env{"x": 1.5, "y": 2.5}
+		want, _ := diff.EvaluateWith(env)
+		got, _ := parsed.EvaluateWith(env)
+		if math.Abs(got-want) > 1e-12 {
+			t.Error("Round trip of", symb.Format(diff), "expected", want, "got", got)
+		}
+		// The debug form is left unchanged:
+		if diff.String() == symb.Format(diff) {
+			t.Error("Expected String to stay fully parenthesized")
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	cases := []struct {
+		a, b        string
+		equal       bool
+		commutative bool
+	}{
+		{"x + y", "x + y", true, true},
+		{"x + y", "y + x", false, true},
+		{"x * sin(y)", "sin(y) * x", false, true},
+		{"x - y", "y - x", false, false},
+		{"x / y", "y / x", false, false},
+		{"x ^ y", "y ^ x", false, false},
+		{"(x + y) + z", "x + (y + z)", false, false},
+		{"sin(x)", "cos(x)", false, false},
+		{"ln(x * 2)", "ln(2 * x)", false, true},
+		{"x + 1", "x + 2", false, false},
+		{"2 * pi", "2 * pi", true, true},
+		{"x", "y", false, false},
+	}
+	for _, c := range cases {
+		a, _ := symb.Parse(c.a)
+		b, _ := symb.Parse(c.b)
+		if got := symb.Equal(a, b); got != c.equal {
+			t.Error("Equal", c.a, c.b, "expected", c.equal, "got", got)
+		}
+		if got := symb.EqualCommutative(a, b); got != c.commutative {
+			t.Error("EqualCommutative", c.a, c.b, "expected", c.commutative, "got", got)
+		}
+		if c.equal && symb.Hash(a) != symb.Hash(b) {
+			t.Error("Expected", c.a, "and", c.b, "to have the same Hash")
+		}
+		if !c.equal && symb.Hash(a) == symb.Hash(b) {
+			t.Error("Expected", c.a, "and", c.b, "to have different Hashes")
+		}
+		if c.commutative && symb.HashCommutative(a) != symb.HashCommutative(b) {
+			t.Error("Expected", c.a, "and", c.b, "to have the same HashCommutative")
+		}
+	}
+
+	// Variables and Constants of the same name differ:
+	e := symb.CreateVariable("e")
+	if symb.Equal(e, symb.GetConstant(symb.ConstantE)) {
+		t.Error("Variable e should differ from constant e")
+	}
+
+	// Hashes can key maps:
+	seen := make(map[uint64]symb.Evaluatable)
+	for _, input := range []string{"x * y", "y * x", "x * y", "x + y"} {
+		expr, _ := symb.Parse(input)
+		seen[symb.Hash(expr)] = expr
+	}
+	if len(seen) != 3 {
+		t.Error("Expected 3 distinct expressions, got", len(seen))
+	}
+
+	// Hashes are stable across runs:
+	x := symb.CreateVariable("x")
+	if symb.Hash(x) != 0x25546a93ff0a48dc {
+		t.Errorf("Unexpected hash for x: %#x", symb.Hash(x))
+	}
+}
+
+// A leaf that counts how many times it is evaluated
+type countingLeaf struct {
+	*symb.Variable
+	count *int
+}
+
+func (c countingLeaf) EvaluateWith(env symb.Env) (float64, error) {
+	*c.count++
+	return c.Variable.EvaluateWith(env)
+}
+
+func TestBuilder(t *testing.T) {
+	b := symb.CreateBuilder()
+	x := symb.CreateVariable("x")
+
+	// Two separate sin(x) become one node:
+	expr := b.Intern(symb.NodeMultiply(symb.NodeSin(x), symb.NodeSin(symb.CreateVariable("x"))))
+	again := b.Intern(symb.NodeSin(x))
+	if !symb.Equal(expr, symb.NodeMultiply(symb.NodeSin(x), symb.NodeSin(x))) {
+		t.Error("Interning should not change the structure, got", expr)
+	}
+	if b.Size() != 3 {
+		t.Error("Expected 3 nodes: x, sin(x) and the product, got", b.Size())
+	}
+	if b.Intern(expr) != expr || b.Size() != 3 {
+		t.Error("Interning twice should return the same node")
+	}
+	if symb.Hash(again) != symb.Hash(symb.NodeSin(x)) {
+		t.Error("Interned nodes keep their Hash")
+	}
+
+	// Derivatives collapse to a much smaller DAG:
+	f, _ := symb.ParseWith("x ^ x * sin(x) ^ 2", x)
+	diff := f
+	for i := 0; i < 3; i++ {
+		diff = b.Diff(diff, x)
+	}
+	// The tree has at least one node per occurrence of x
+	leaves := strings.Count(diff.String(), "x")
+	if b.Size() >= leaves/2 {
+		t.Error("Expected the DAG to be much smaller than the tree, got", b.Size(), "nodes for", leaves, "leaves")
+	}
+	values, err := symb.EvaluateShared(symb.Env{"x": 1.3}, diff)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	want, _ := diff.EvaluateWith(symb.Env{"x": 1.3})
+	if values[0] != want {
+		t.Error("Expected", want, "got", values[0])
+	}
+	if !symb.Equal(diff, f.Diff(x).Diff(x).Diff(x)) {
+		t.Error("Expected the same derivative as Diff, got", diff)
+	}
+
+	// Each derivative is computed once per interned node, higher orders stay cheap:
+	for i := 3; i < 8; i++ {
+		diff = b.Diff(diff, x)
+	}
+	if b.Size() > 2000 {
+		t.Error("Expected the eighth derivative to take less than 2000 nodes, got", b.Size())
+	}
+	if b.Diff(diff, x) != b.Diff(diff, x) {
+		t.Error("Derivatives should be memoized")
+	}
+}
+
+func TestEvaluateShared(t *testing.T) {
+	count := 0
+	leaf := countingLeaf{symb.CreateVariable("x"), &count}
+	s := symb.NodeSin(leaf)
+	f := symb.NodeAdd(symb.NodeMultiply(s, s), s)
+	g := symb.NodeCos(s)
+
+	values, err := symb.EvaluateShared(symb.Env{"x": 0.5}, f, g)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if count != 1 {
+		t.Error("Expected the shared leaf to be evaluated once, got", count)
+	}
+	sin := math.Sin(0.5)
+	if values[0] != sin*sin+sin || values[1] != math.Cos(sin) {
+		t.Error("Wrong values", values)
+	}
+
+	_, err = symb.EvaluateShared(symb.Env{"x": 0.0}, symb.NodeDivide(f, leaf))
+	if !errors.Is(err, symb.ErrDivisionByZero) {
+		t.Error("Expected ErrDivisionByZero, got", err)
+	}
+}
+
+func TestCSE(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	f, _ := symb.ParseWith("sin(x * y) * cos(x * y) + sin(x * y)", x, y)
+	g, _ := symb.ParseWith("cos(x * y) ^ 2", x, y)
+
+	program := symb.CSE(f, g)
+	temps := []string{"t0 = x * y", "t1 = sin(t0)", "t2 = cos(t0)"}
+	if len(program.Temporaries) != len(temps) {
+		t.Fatal("Expected", len(temps), "temporaries, got", len(program.Temporaries))
+	}
+	for i, temp := range program.Temporaries {
+		if got := temp.Var.GetName() + " = " + symb.Format(temp.Expr); got != temps[i] {
+			t.Error("Expected", temps[i], "got", got)
+		}
+	}
+	outputs := []string{"t1 * t2 + t1", "t2 ^ 2"}
+	for i, output := range program.Outputs {
+		if got := symb.Format(output); got != outputs[i] {
+			t.Error("Expected", outputs[i], "got", got)
+		}
+	}
+	if program.Report.TreeNodes != 20 || program.Report.Nodes != 15 || program.Report.Saved() != 5 {
+		t.Error("Unexpected report", program.Report)
+	}
+
+	env := symb.Env{"x": 0.3, "y": 1.7}
+	values, err := program.Evaluate(env)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for i, expr := range []symb.Evaluatable{f, g} {
+		want, _ := expr.EvaluateWith(env)
+		if values[i] != want {
+			t.Error("Output", i, "expected", want, "got", values[i])
+		}
+	}
+}
+
+func TestCSEJacobian(t *testing.T) {
+	system := symb.CreateEquationSystem()
+	system.ParseEquation("sin(x) * y ^ 2 = ln(x * y)")
+	system.ParseEquation("cos(x * y) = x ^ y")
+	jac := symb.CreateJacobian(system.Residuals(), system.Variables())
+	var entries []symb.Evaluatable
+	for _, row := range jac.Entries {
+		entries = append(entries, row...)
+	}
+	program := symb.CSE(entries...)
+	if program.Report.Saved() <= 0 {
+		t.Error("Expected CSE to save nodes, got", program.Report)
+	}
+	env := symb.Env{"x": 1.2, "y": 0.7}
+	values, err := program.Evaluate(env)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	dense, _ := jac.Evaluate(env)
+	for i, row := range dense {
+		for j, want := range row {
+			if got := values[i*len(row)+j]; got != want {
+				t.Error("Entry", i, j, "expected", want, "got", got)
+			}
+		}
+	}
+
+	// Temporaries do not clash with the variables:
+	t0 := symb.CreateVariable("t0")
+	program = symb.CSE(symb.NodeAdd(symb.NodeSin(t0), symb.NodeSin(t0)))
+	if name := program.Temporaries[0].Var.GetName(); name != "_t0" {
+		t.Error("Expected temporary _t0, got", name)
+	}
+
+	// Repeated constants stay inline:
+	minusTwo := symb.NodeNeg(symb.GetConstantValue(2))
+	program = symb.CSE(symb.NodeAdd(symb.NodeMultiply(minusTwo, t0), symb.NodePow(minusTwo, t0)))
+	if len(program.Temporaries) != 0 {
+		t.Error("Expected no temporaries, got", len(program.Temporaries))
+	}
+}
+
+const benchmarkExpression = "x ^ 2 * sin(y) + ln(x * y) / cos(x - y) - 3 * x * y"
+
+func TestCompile(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	vars := []*symb.Variable{x, y}
+	for _, input := range []string{
+		benchmarkExpression,
+		"x",
+		"2 * pi",
+		"x ^ y ^ 2 - (x - y) / (x + y)",
+		"-x * -(y - 1)",
+	} {
+		expr, _ := symb.ParseWith(input, x, y)
+		program, err := symb.Compile(expr, vars)
+		if err != nil {
+			t.Fatal("Unexpected error compiling", input, err)
+		}
+		for _, point := range [][]float64{{1.5, 0.5}, {2.0, 3.0}, {0.1, 7.2}} {
+			want, _ := expr.EvaluateWith(symb.Env{"x": point[0], "y": point[1]})
+			if got := program.Eval(point); got != want {
+				t.Error("Compiled", input, "at", point, "expected", want, "got", got)
+			}
+		}
+	}
+
+	expr, _ := symb.ParseWith(benchmarkExpression, x, y)
+	program, _ := symb.Compile(expr, vars)
+	inputs := []float64{1.5, 0.5}
+	allocs := testing.AllocsPerRun(100, func() {
+		program.Eval(inputs)
+	})
+	if allocs != 0 {
+		t.Error("Expected Eval not to allocate, got", allocs)
+	}
+	if clone := program.Clone(); clone.Eval(inputs) != program.Eval(inputs) {
+		t.Error("Expected the clone to give the same result")
+	}
+
+	// Domain errors do not panic:
+	expr, _ = symb.ParseWith("ln(x) + 1 / y", x, y)
+	program, _ = symb.Compile(expr, vars)
+	if got := program.Eval([]float64{-1.0, 1.0}); !math.IsNaN(got) {
+		t.Error("Expected NaN, got", got)
+	}
+	if got := program.Eval([]float64{1.0, 0.0}); !math.IsInf(got, 1) {
+		t.Error("Expected +Inf, got", got)
+	}
+
+	_, err := symb.Compile(expr, []*symb.Variable{x})
+	if !errors.Is(err, symb.ErrUnboundVariable) {
+		t.Error("Expected ErrUnboundVariable, got", err)
+	}
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, _ := symb.ParseWith(benchmarkExpression, x, y)
+	x.SetValue(1.5)
+	y.SetValue(0.5)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		expr.Evaluate()
+	}
+}
+
+func BenchmarkEvaluateWith(b *testing.B) {
+	expr, _ := symb.Parse(benchmarkExpression)
+	env := symb.Env{"x": 1.5, "y": 0.5}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		expr.EvaluateWith(env)
+	}
+}
+
+func BenchmarkProgramEval(b *testing.B) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, _ := symb.ParseWith(benchmarkExpression, x, y)
+	program, _ := symb.Compile(expr, []*symb.Variable{x, y})
+	inputs := []float64{1.5, 0.5}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		program.Eval(inputs)
+	}
+}
+
+func TestEvaluateBatch(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	vars := []*symb.Variable{x, y}
+	expr, _ := symb.ParseWith(benchmarkExpression, x, y)
+
+	// More points than a chunk, and not a multiple of it:
+	n := 1000
+	columns := [][]float64{make([]float64, n), make([]float64, n)}
+	for i := 0; i < n; i++ {
+		columns[0][i] = 0.1 + float64(i)*0.013
+		columns[1][i] = 2.5 - float64(i)*0.002
+	}
+	out := make([]float64, n)
+	if err := symb.EvaluateBatch(expr, vars, columns, out); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for i := 0; i < n; i++ {
+		x.SetValue(columns[0][i])
+		y.SetValue(columns[1][i])
+		if want := expr.Evaluate(); math.Float64bits(out[i]) != math.Float64bits(want) {
+			t.Fatal("Point", i, "expected", want, "got", out[i])
+		}
+	}
+
+	parallel := make([]float64, n)
+	if err := symb.EvaluateBatchParallel(expr, vars, columns, parallel, 3); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for i := range out {
+		if math.Float64bits(out[i]) != math.Float64bits(parallel[i]) {
+			t.Fatal("Parallel point", i, "expected", out[i], "got", parallel[i])
+		}
+	}
+
+	err := symb.EvaluateBatch(expr, vars, columns[:1], out)
+	if !errors.Is(err, symb.ErrDimension) {
+		t.Error("Expected ErrDimension, got", err)
+	}
+	err = symb.EvaluateBatch(expr, vars, columns, out[:10])
+	if !errors.Is(err, symb.ErrDimension) {
+		t.Error("Expected ErrDimension, got", err)
+	}
+}
+
+func BenchmarkEvaluateBatch(b *testing.B) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, _ := symb.ParseWith(benchmarkExpression, x, y)
+	program, _ := symb.Compile(expr, []*symb.Variable{x, y})
+	n := 10000
+	columns := [][]float64{make([]float64, n), make([]float64, n)}
+	for i := 0; i < n; i++ {
+		columns[0][i] = 1.0 + float64(i)*1e-4
+		columns[1][i] = 0.5
+	}
+	out := make([]float64, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		program.EvalBatch(columns, out)
+	}
+}
+
+func TestGenerateGo(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, _ := symb.ParseWith("sin(y) * x ^ 2 + sin(y) / 2 - pi", x, y)
+
+	source, err := symb.GenerateGo("gen", symb.GoFunction{Name: "F", Vars: []*symb.Variable{x, y}, Exprs: []symb.Evaluatable{expr}})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := `// Code generated by symbolic-algebra. DO NOT EDIT.
+
+package gen
+
+import "math"
+
+// F returns sin(y) * x ^ 2 + sin(y) / 2 - pi
+func F(x, y float64) float64 {
+	t0 := math.Sin(y)
+	return t0*math.Pow(x, 2.0) + t0/2.0 - math.Pi
+}
+`
+	if string(source) != expected {
+		t.Error("Expected:\n" + expected + "got:\n" + string(source))
+	}
+
+	// A parameter named like a temporary, several outputs
+	t0 := symb.CreateVariable("t0")
+	grad := []symb.Evaluatable{expr.Diff(x).Trim(), expr.Diff(y).Trim()}
+	source, err = symb.GenerateGo("gen", symb.GoFunction{Name: "Grad", Vars: []*symb.Variable{x, y, t0}, Exprs: grad})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected = `// Code generated by symbolic-algebra. DO NOT EDIT.
+
+package gen
+
+import "math"
+
+// Grad writes its 2 outputs into out
+func Grad(x, y, t0 float64, out []float64) {
+	_t0 := math.Cos(y)
+	out[0] = math.Sin(y) * (2.0 * x)
+	out[1] = _t0*math.Pow(x, 2.0) + _t0/2.0
+}
+`
+	if string(source) != expected {
+		t.Error("Expected:\n" + expected + "got:\n" + string(source))
+	}
+
+	// math has no sign function, the generated one declares it
+	abs := symb.NodeAbs(symb.NodeSub(x, y))
+	source, err = symb.GenerateGo("gen", symb.GoFunction{Name: "D", Vars: []*symb.Variable{x, y}, Exprs: []symb.Evaluatable{abs.Diff(x).Trim()}})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !strings.Contains(string(source), "sign := func(x float64) float64 {") || !strings.Contains(string(source), "return sign(x - y)") {
+		t.Error("Expected a sign function, got:\n" + string(source))
+	}
+
+	_, err = symb.GenerateGo("gen", symb.GoFunction{Name: "F", Vars: []*symb.Variable{x}, Exprs: []symb.Evaluatable{expr}})
+	if !errors.Is(err, symb.ErrUnboundVariable) {
+		t.Error("Expected ErrUnboundVariable, got", err)
+	}
+	_, err = symb.GenerateGo("gen", symb.GoFunction{Name: "func", Vars: []*symb.Variable{x, y}, Exprs: []symb.Evaluatable{expr}})
+	if err == nil {
+		t.Error("Expected an error for a keyword as function name")
+	}
+}
+
+func TestGenerateGoConstants(t *testing.T) {
+	x := symb.CreateVariable("x")
+	overflow, _ := symb.ParseWith("(1e300 * 1e300) * x", x)
+	rounded, _ := symb.ParseWith("(0.1 + 0.2) + x", x)
+	source, err := symb.GenerateGo("gen",
+		symb.GoFunction{Name: "Overflow", Vars: []*symb.Variable{x}, Exprs: []symb.Evaluatable{overflow}},
+		symb.GoFunction{Name: "Rounded", Vars: []*symb.Variable{x}, Exprs: []symb.Evaluatable{rounded}},
+	)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	// Constants are computed in float64 like the tree does
+	for _, want := range []string{"return math.Inf(1) * x", "return 0.30000000000000004 + x"} {
+		if !strings.Contains(string(source), want) {
+			t.Error("Expected", want, "in:\n"+string(source))
+		}
+	}
+
+	// The source type checks, the Go compiler would have rejected 1e+300 * 1e+300
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "gen.go", source, 0)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("gen", fset, []*ast.File{file}, nil); err != nil {
+		t.Error("Generated code does not compile:", err)
+	}
+
+	zero := symb.NodeSub(symb.GetConstantValue(1), symb.GetConstantValue(1))
+	_, err = symb.GenerateGo("gen", symb.GoFunction{Name: "F", Vars: []*symb.Variable{x}, Exprs: []symb.Evaluatable{symb.NodeDivide(x, zero)}})
+	if !errors.Is(err, symb.ErrDivisionByZero) {
+		t.Error("Expected ErrDivisionByZero, got", err)
+	}
+}
+
+var update = flag.Bool("update", false, "rewrite the golden files of the code generators")
+
+// Compares got with the golden file testdata/name, rewriting it with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file, got:\n%s", name, got)
+	}
+}
+
+// One function per node kind of Arithmetic.go, Functions.go, Inverse.go, Hyperbolic.go and Log.go,
+// plus a vector and a matrix
+func codeFunctions() []symb.CodeFunction {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	xy := []*symb.Variable{x, y}
+	one := func(name string, e symb.Evaluatable) symb.CodeFunction {
+		return symb.CodeFunction{Name: name, Vars: xy, Exprs: []symb.Evaluatable{e}}
+	}
+	nested, _ := symb.ParseWith("(x - (y - 1)) / (x * -2) - x ^ y ^ 2 + (-2) ^ x * pi / e", x, y)
+	f, _ := symb.ParseWith("x ^ 2 * sin(y) + sin(y) / x", x, y)
+	g, _ := symb.ParseWith("ln(x * y)", x, y)
+	product, _ := symb.ParseWith("x ^ (x * y)", x, y)
+	scaled, _ := symb.ParseWith("x ^ (-2 * y)", x, y)
+	return []symb.CodeFunction{
+		one("add", symb.NodeAdd(x, y)),
+		one("sub", symb.NodeSub(x, y)),
+		one("multiply", symb.NodeMultiply(x, y)),
+		one("divide", symb.NodeDivide(x, y)),
+		one("negate", symb.NodeNeg(symb.NodeMultiply(x, y))),
+		one("power", symb.NodePow(x, y)),
+		one("powerproduct", product),
+		one("powerscaled", scaled),
+		one("ln", symb.NodeLn(x)),
+		one("sine", symb.NodeSin(x)),
+		one("cosine", symb.NodeCos(y)),
+		one("tangent", symb.NodeTan(x)),
+		one("exponential", symb.NodeExp(x)),
+		one("root", symb.NodeSqrt(x)),
+		one("absolute", symb.NodeAbs(x)),
+		one("signum", symb.NodeSign(x)),
+		one("arcsine", symb.NodeAsin(x)),
+		one("arccosine", symb.NodeAcos(x)),
+		one("arctangent", symb.NodeAtan(x)),
+		one("angle", symb.NodeAtan2(y, x)),
+		one("hsine", symb.NodeSinh(x)),
+		one("hcosine", symb.NodeCosh(x)),
+		one("htangent", symb.NodeTanh(x)),
+		one("areasine", symb.NodeAsinh(x)),
+		one("areacosine", symb.NodeAcosh(x)),
+		one("areatangent", symb.NodeAtanh(x)),
+		one("decimal", symb.NodeLog10(x)),
+		one("binary", symb.NodeLog2(x)),
+		one("logarithm", symb.NodeLog(y, x)),
+		one("nested", nested),
+		{Name: "gradient", Vars: xy, Exprs: []symb.Evaluatable{f.Diff(x).Trim(), f.Diff(y).Trim()}},
+		symb.MatrixFunction("jacobian", xy, symb.Jacobian([]symb.Evaluatable{f, g}, xy)),
+	}
+}
+
+func TestGenerateC(t *testing.T) {
+	source, err := symb.GenerateC(nil, codeFunctions()...)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	checkGolden(t, "codegen.c", source)
+}
+
+func TestGeneratePython(t *testing.T) {
+	source, err := symb.GeneratePython(nil, codeFunctions()...)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	checkGolden(t, "codegen.py", source)
+}
+
+func TestCodeOptions(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, _ := symb.ParseWith("sin(x) ^ 2 + ln(y)", x, y)
+
+	opts := &symb.CodeOptions{Functions: map[string]string{"sin": "sinf", "^": "powf"}}
+	got, _ := symb.CExpr(expr, opts)
+	if expected := "powf(sinf(x), 2.0) + log(y)"; got != expected {
+		t.Error("Expected", expected, "got", got)
+	}
+	got, _ = symb.PythonExpr(expr, &symb.CodeOptions{Functions: map[string]string{"ln": "numpy.log"}})
+	if expected := "np.sin(x) ** 2.0 + numpy.log(y)"; got != expected {
+		t.Error("Expected", expected, "got", got)
+	}
+
+	// Parameters follow the given order
+	f := symb.CodeFunction{Name: "f", Vars: []*symb.Variable{y, x}, Exprs: []symb.Evaluatable{expr}}
+	source, _ := symb.GenerateC(opts, f)
+	if !strings.Contains(source, "double f(double y, double x)") {
+		t.Error("Expected the parameters in the given order, got", source)
+	}
+
+	// A parameter can not shadow a function the code calls
+	sin := symb.CreateVariable("sin")
+	f = symb.CodeFunction{Name: "f", Vars: []*symb.Variable{sin}, Exprs: []symb.Evaluatable{symb.NodeSin(sin)}}
+	if _, err := symb.GenerateC(nil, f); err == nil {
+		t.Error("Expected an error for a parameter named sin")
+	}
+	if _, err := symb.GeneratePython(nil, f); err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	f = symb.CodeFunction{Name: "f", Vars: []*symb.Variable{x}, Exprs: []symb.Evaluatable{expr}}
+	if _, err := symb.GeneratePython(nil, f); !errors.Is(err, symb.ErrUnboundVariable) {
+		t.Error("Expected ErrUnboundVariable, got", err)
+	}
+}
+
+func TestDiffN(t *testing.T) {
+	x := symb.CreateVariable("x")
+	cube := symb.NodePow(x, symb.GetConstantValue(3))
+	if got := symb.DiffN(cube, x, 0); got != cube {
+		t.Error("Expected the expression itself, got", got)
+	}
+	if got := symb.Format(symb.DiffN(cube, x, 2)); got != "3 * (2 * x)" {
+		t.Error("Expected 3 * (2 * x), got", got)
+	}
+	if got := symb.DiffN(cube, x, 4); !got.IsConstant() || got.Evaluate() != 0.0 {
+		t.Error("Expected 0, got", got)
+	}
+
+	// The fourth derivative of sin is sin itself
+	sin := symb.NodeSin(x)
+	fourth := symb.DiffN(sin, x, 4)
+	for _, value := range []float64{-1.0, 0.3, 2.0} {
+		x.SetValue(value)
+		if got, want := fourth.Evaluate(), sin.Evaluate(); math.Abs(got-want) > 1e-15 {
+			t.Error("At", value, "expected", want, "got", got)
+		}
+	}
+}
+
+func TestDiffMulti(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	f, _ := symb.ParseWith("x ^ 3 * y ^ 2 + sin(x * y)", x, y)
+
+	d := symb.CreateDerivatives(f)
+	fxy := d.Partial(x, y)
+	if fyx := d.Partial(y, x); fyx != fxy {
+		t.Error("Expected f_xy and f_yx to be the same derivative, got", fxy, "and", fyx)
+	}
+	fxxy := symb.DiffMulti(f, []*symb.Variable{x, y, x})
+	x.SetValue(0.7)
+	y.SetValue(-1.3)
+	// f_xxy = 12 x y - 2 y sin(x y) - x y^2 cos(x y)
+	xv, yv := 0.7, -1.3
+	want := 12*xv*yv - 2*yv*math.Sin(xv*yv) - xv*yv*yv*math.Cos(xv*yv)
+	if got := fxxy.Evaluate(); math.Abs(got-want) > 1e-12 {
+		t.Error("Expected", want, "got", got)
+	}
+	if got := symb.DiffMulti(f, nil); got != f {
+		t.Error("Expected the expression itself, got", got)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	z := symb.CreateVariable("z")
+	f, _ := symb.ParseWith("x ^ 2 * y + sin(y)", x, y)
+
+	gradient := symb.Gradient(f, []*symb.Variable{x, y, z})
+	expected := []string{"2 * x * y", "x ^ 2 + cos(y)", "0"}
+	for i, entry := range gradient {
+		if got := symb.Format(entry); got != expected[i] {
+			t.Error("Entry", i, "expected", expected[i], "got", got)
+		}
+	}
+
+	dst := make([]float64, 3)
+	if err := symb.EvaluateGradient(gradient, symb.Env{"x": 3.0, "y": 0.0}, dst); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if dst[0] != 0.0 || dst[1] != 10.0 || dst[2] != 0.0 {
+		t.Error("Expected [0 10 0], got", dst)
+	}
+	err := symb.EvaluateGradient(gradient, symb.Env{"x": 3.0}, dst)
+	if !errors.Is(err, symb.ErrUnboundVariable) {
+		t.Error("Expected ErrUnboundVariable, got", err)
+	}
+}
+
+func TestHessian(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	z := symb.CreateVariable("z")
+	f, _ := symb.ParseWith("x ^ 3 * y + ln(y)", x, y)
+	vars := []*symb.Variable{x, y, z}
+
+	hessian := symb.Hessian(f, vars)
+	for i := range vars {
+		for j := range vars {
+			if hessian[i][j] != hessian[j][i] {
+				t.Error("Expected entries", i, j, "to be shared, got", hessian[i][j], "and", hessian[j][i])
+			}
+		}
+	}
+	if got := symb.Format(hessian[2][0]); got != "0" {
+		t.Error("Expected 0, got", got)
+	}
+
+	dst := make([][]float64, 3)
+	for i := range dst {
+		dst[i] = make([]float64, 3)
+	}
+	if err := symb.EvaluateHessian(hessian, symb.Env{"x": 2.0, "y": 0.5}, dst); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	// f_xx = 6 x y, f_xy = 3 x^2, f_yy = -1 / y^2
+	expected := [][]float64{{6.0, 12.0, 0.0}, {12.0, -4.0, 0.0}, {0.0, 0.0, 0.0}}
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(dst[i][j]-expected[i][j]) > 1e-12 {
+				t.Error("Entry", i, j, "expected", expected[i][j], "got", dst[i][j])
+			}
+		}
+	}
+}
+
+func TestTaylor(t *testing.T) {
+	x := symb.CreateVariable("x")
+	sin := symb.NodeSin(x)
+	taylor, err := symb.Taylor(sin, x, symb.GetConstant(symb.ConstantZero), 5)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := symb.Format(taylor); got != "x + -0.16666666666666666 * x ^ 3 + 0.008333333333333333 * x ^ 5" {
+		t.Error("Unexpected expansion of sin:", got)
+	}
+
+	ln := symb.NodeLn(x)
+	series, err := symb.ExpandTaylor(ln, []*symb.Variable{x}, []symb.Evaluatable{symb.GetConstant(symb.ConstantOne)}, 3)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := symb.Format(series.Remainder); got != "-0.25 * (x - 1) ^ 4" {
+		t.Error("Expected -0.25 * (x - 1) ^ 4, got", got)
+	}
+	// The error is close to the estimate near the point
+	x.SetValue(1.05)
+	diff := ln.Evaluate() - series.Polynomial.Evaluate()
+	if estimate := series.Remainder.Evaluate(); math.Abs(diff-estimate) > 0.1*math.Abs(estimate) {
+		t.Error("Expected an error close to", estimate, "got", diff)
+	}
+
+	// No expansion where a derivative is not defined
+	zero := symb.GetConstant(symb.ConstantZero)
+	if _, err = symb.Taylor(symb.NodeSqrt(x), x, zero, 2); !errors.Is(err, symb.ErrDivisionByZero) {
+		t.Error("Expected ErrDivisionByZero, got", err)
+	}
+	var domainErr *symb.ErrDomain
+	if _, err = symb.Taylor(ln, x, zero, 2); !errors.As(err, &domainErr) {
+		t.Error("Expected an ErrDomain, got", err)
+	}
+	// x ^ 2.5 is twice differentiable at 0 but its third derivative is not defined
+	series, err = symb.ExpandTaylor(symb.NodePow(x, symb.GetConstantValue(2.5)), []*symb.Variable{x}, []symb.Evaluatable{zero}, 2)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := symb.Format(series.Polynomial); got != "0" || series.Remainder != nil {
+		t.Error("Expected 0 without remainder, got", got, series.Remainder)
+	}
+}
+
+func TestExpandTaylor(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	zero := symb.GetConstant(symb.ConstantZero)
+
+	f, _ := symb.ParseWith("sin(x) * cos(y)", x, y)
+	series, err := symb.ExpandTaylor(f, []*symb.Variable{x, y}, []symb.Evaluatable{zero, zero}, 2)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := symb.Format(series.Polynomial); got != "x" {
+		t.Error("Expected x, got", got)
+	}
+	if got := symb.Format(series.Remainder); got != "-0.16666666666666666 * x ^ 3 + -0.5 * x * y ^ 2" {
+		t.Error("Unexpected remainder:", got)
+	}
+
+	// A polynomial is its own expansion
+	g, _ := symb.ParseWith("x ^ 2 * y + x", x, y)
+	point := []symb.Evaluatable{symb.GetConstantValue(1), symb.GetConstantValue(2)}
+	if series, err = symb.ExpandTaylor(g, []*symb.Variable{x, y}, point, 3); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := symb.Format(series.Remainder); got != "0" {
+		t.Error("Expected no remainder, got", got)
+	}
+	for _, values := range [][2]float64{{0.0, 0.0}, {1.5, -2.0}, {-3.0, 4.0}} {
+		x.SetValue(values[0])
+		y.SetValue(values[1])
+		if got, want := series.Polynomial.Evaluate(), g.Evaluate(); math.Abs(got-want) > 1e-12 {
+			t.Error("At", values, "expected", want, "got", got)
+		}
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, _ := symb.ParseWith("x * y + sin(y)", x, y)
+
+	twoX := symb.NodeMultiply(symb.GetConstantValue(2), x)
+	substituted := symb.Substitute(expr, map[*symb.Variable]symb.Evaluatable{y: twoX})
+	if got := symb.Format(substituted); got != "x * (2 * x) + sin(2 * x)" {
+		t.Error("Expected x * (2 * x) + sin(2 * x), got", got)
+	}
+	if got := symb.Format(expr); got != "x * y + sin(y)" {
+		t.Error("Expected the original to be unchanged, got", got)
+	}
+
+	// Replacements are simultaneous
+	swapped := symb.Substitute(expr, map[*symb.Variable]symb.Evaluatable{x: y, y: x})
+	if got := symb.Format(swapped); got != "y * x + sin(x)" {
+		t.Error("Expected y * x + sin(x), got", got)
+	}
+}
+
+func TestPartialEval(t *testing.T) {
+	x := symb.CreateVariable("x")
+	y := symb.CreateVariable("y")
+	expr, _ := symb.ParseWith("x * y + ln(y) * 2", x, y)
+
+	partial, err := symb.PartialEval(expr, symb.Env{"y": 1.0})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := symb.Format(partial); got != "x" {
+		t.Error("Expected x, got", got)
+	}
+	if partial, err = symb.PartialEval(expr, symb.Env{"y": 2.0}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := symb.Format(partial); got != "x * 2 + 1.3862943611198906" {
+		t.Error("Expected x * 2 + 1.3862943611198906, got", got)
+	}
+	if partial.FunctionOf(y) {
+		t.Error("Expected y to be bound")
+	}
+	if value, _ := partial.EvaluateWith(symb.Env{"x": 3.0}); value != 6.0+2*math.Log(2.0) {
+		t.Error("Expected", 6.0+2*math.Log(2.0), "got", value)
+	}
+
+	// Bindings that make a part undefined are reported
+	f, _ := symb.ParseWith("1 / (x - 2)", x)
+	if _, err = symb.PartialEval(f, symb.Env{"x": 2.0}); !errors.Is(err, symb.ErrDivisionByZero) {
+		t.Error("Expected ErrDivisionByZero, got", err)
+	}
+	g, _ := symb.ParseWith("x ^ y + 1", x, y)
+	var domainErr *symb.ErrDomain
+	if _, err = symb.PartialEval(g, symb.Env{"x": 0.0, "y": 0.0}); !errors.As(err, &domainErr) {
+		t.Error("Expected an ErrDomain, got", err)
+	}
+}
+
+func TestPartialEvalConcurrent(t *testing.T) {
+	expr, _ := symb.Parse("x * y + y")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(offset int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				value := float64(offset*1000+j) + 0.25
+				partial, err := symb.PartialEval(expr, symb.Env{"y": value})
+				if err != nil {
+					t.Error("Unexpected error:", err)
+					return
+				}
+				if got, _ := partial.EvaluateWith(symb.Env{"x": 2.0}); got != 3*value {
+					t.Error("Expected", 3*value, "got", got)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestNodeFunctions(t *testing.T) {
+	x := symb.CreateVariable("x")
+	tests := []struct {
+		expr     symb.Evaluatable
+		value    float64
+		expected float64
+	}{
+		{symb.NodeTan(x), math.Pi / 4.0, 1.0},
+		{symb.NodeExp(x), 1.0, math.E},
+		{symb.NodeSqrt(x), 2.25, 1.5},
+		{symb.NodeAbs(x), -3.0, 3.0},
+		{symb.NodeSign(x), -3.0, -1.0},
+		{symb.NodeSign(x), 0.0, 0.0},
+		{symb.NodeAsin(x), 1.0, math.Pi / 2.0},
+		{symb.NodeAcos(x), -1.0, math.Pi},
+		{symb.NodeAtan(x), 1.0, math.Pi / 4.0},
+		{symb.NodeAtan2(x, symb.GetConstantValue(-1.0)), 0.0, math.Pi},
+	}
+	for _, test := range tests {
+		x.SetValue(test.value)
+		if got := test.expr.Evaluate(); math.Abs(got-test.expected) > 1e-12 {
+			t.Error(test.expr, "at", test.value, "expected", test.expected, "got", got)
+		}
+	}
+
+	// tan follows math.Tan, pi/2 is not exactly a pole
+	tan := symb.NodeTan(x)
+	if got, err := tan.EvaluateWith(symb.Env{"x": math.Pi / 2.0}); err != nil || got != math.Tan(math.Pi/2.0) {
+		t.Error("Expected", math.Tan(math.Pi/2.0), "got", got, err)
+	}
+}
+
+func TestFunctionDomains(t *testing.T) {
+	tests := []struct {
+		input string
+		fn    string
+	}{
+		{"sqrt(-1)", "sqrt"},
+		{"asin(2)", "asin"},
+		{"acos(-1.5)", "acos"},
+		{"atan2(0, 0)", "atan2"},
+	}
+	for _, test := range tests {
+		expr, _ := symb.Parse(test.input)
+		_, err := expr.EvaluateErr()
+		var domain *symb.ErrDomain
+		if !errors.As(err, &domain) || domain.Func != test.fn {
+			t.Error(test.input, "expected a domain error of", test.fn, "got", err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Evaluate to panic out of the domain")
+		}
+	}()
+	symb.NodeSqrt(symb.GetConstantValue(-1.0)).Evaluate()
+}
+
+func TestDiffWithFunctions(t *testing.T) {
+	x := symb.CreateVariable("x")
+	two := symb.GetConstantValue(2.0)
+
+	expr := symb.NodeExp(symb.NodeMultiply(two, x)).Diff(x).String()
+	if expr != "(exp((2 * x)) * (2 * 1))" {
+		t.Error("Expected (exp((2 * x)) * (2 * 1)), got", expr)
+	}
+	expr = symb.NodeAbs(symb.NodeMultiply(two, x)).Diff(x).String()
+	if expr != "(sign((2 * x)) * (2 * 1))" {
+		t.Error("Expected (sign((2 * x)) * (2 * 1)), got", expr)
+	}
+
+	// Compare every derivative with central differences
+	y := symb.CreateVariable("y")
+	inputs := []string{"tan(x)", "exp(x)", "sqrt(x)", "abs(x)", "asin(x)", "acos(x)", "atan(x)", "atan2(x, y)", "atan2(y, x)"}
+	for _, input := range inputs {
+		f, _ := symb.ParseWith(input, x, y)
+		derivative := f.Diff(x)
+		for _, value := range []float64{-0.6, 0.3, 0.8} {
+			if input == "sqrt(x)" && value < 0.0 {
+				continue
+			}
+			h := 1e-6
+			at := func(v float64) float64 {
+				result, _ := f.EvaluateWith(symb.Env{"x": v, "y": 0.7})
+				return result
+			}
+			want := (at(value+h) - at(value-h)) / (2.0 * h)
+			got, err := derivative.EvaluateWith(symb.Env{"x": value, "y": 0.7})
+			if err != nil || math.Abs(got-want) > 1e-6 {
+				t.Error("d/dx", input, "at", value, "expected", want, "got", got, err)
+			}
+		}
+	}
+}
+
+func TestTrimWithFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"tan(0) + x", "x"},
+		{"exp(0) * x", "x"},
+		{"exp(1)", "e"},
+		{"exp(1000) * x", "exp(1000) * x"},
+		{"sqrt(1 * x ^ 2)", "abs(x)"},
+		{"sqrt(4)", "2"},
+		{"sqrt(-4)", "sqrt(-4)"},
+		{"abs(abs(x))", "abs(x)"},
+		{"abs(-3)", "3"},
+		{"sign(sign(x))", "sign(x)"},
+		{"sign(-2)", "-1"},
+		{"asin(0) + acos(1) + atan(0)", "0"},
+		{"asin(2)", "asin(2)"},
+		{"atan2(1, 1)", "0.7853981633974483"},
+		{"atan2(0, 0)", "atan2(0, 0)"},
+	}
+	for _, test := range tests {
+		expr, err := symb.Parse(test.input)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if got := symb.Format(expr.Trim()); got != test.expected {
+			t.Error(test.input, "expected", test.expected, "got", got)
+		}
+	}
+}
+
+func TestParseFunctions(t *testing.T) {
+	for _, input := range []string{"atan2(y, x ^ 2)", "sqrt(abs(x)) - exp(-x)", "tan(asin(x) + acos(x) * atan(x))", "sign(x)"} {
+		expr, err := symb.Parse(input)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if got := symb.Format(expr); got != input {
+			t.Error("Expected", input, "got", got)
+		}
+	}
+	for _, input := range []string{"atan2(y)", "atan2(y, x, z)", "atan2", "sqrt(x, y)"} {
+		if _, err := symb.Parse(input); err == nil {
+			t.Error("Expected an error for", input)
+		}
+	}
+
+	expr, _ := symb.Parse("sqrt(x) + abs(y) * asin(x)")
+	if got := symb.LaTeX(expr); got != "\\sqrt{x} + \\left|y\\right|\\arcsin\\left(x\\right)" {
+		t.Error("Unexpected LaTeX:", got)
+	}
+}
+
+func TestNodeSinh(t *testing.T) {
+	x := symb.CreateVariable("x")
+	sinh := symb.NodeSinh(x)
+
+	// sinh(0) = 0.0
+	x.SetValue(0.0)
+	got := sinh.Evaluate()
+	if math.Abs(got-0.0) > 1e-10 {
+		t.Error("Expected 0.0, got", got)
+	}
+
+	// sinh(ln(2)) = 0.75
+	x.SetValue(math.Ln2)
+	got = sinh.Evaluate()
+	if math.Abs(got-0.75) > 1e-10 {
+		t.Error("Expected 0.75, got", got)
+	}
+
+	// sinh(-ln(2)) = -0.75
+	x.SetValue(-math.Ln2)
+	got = sinh.Evaluate()
+	if math.Abs(got+0.75) > 1e-10 {
+		t.Error("Expected -0.75, got", got)
+	}
+}
+
+func TestNodeCosh(t *testing.T) {
+	x := symb.CreateVariable("x")
+	cosh := symb.NodeCosh(x)
+
+	// cosh(0) = 1.0
+	x.SetValue(0.0)
+	got := cosh.Evaluate()
+	if math.Abs(got-1.0) > 1e-10 {
+		t.Error("Expected 1.0, got", got)
+	}
+
+	// cosh(ln(2)) = 1.25
+	x.SetValue(math.Ln2)
+	got = cosh.Evaluate()
+	if math.Abs(got-1.25) > 1e-10 {
+		t.Error("Expected 1.25, got", got)
+	}
+
+	// cosh(-ln(2)) = 1.25
+	x.SetValue(-math.Ln2)
+	got = cosh.Evaluate()
+	if math.Abs(got-1.25) > 1e-10 {
+		t.Error("Expected 1.25, got", got)
+	}
+}
+
+func TestNodeHyperbolic(t *testing.T) {
+	x := symb.CreateVariable("x")
+	tests := []struct {
+		expr     symb.Evaluatable
+		value    float64
+		expected float64
+	}{
+		{symb.NodeTanh(x), math.Ln2, 0.6},
+		{symb.NodeAsinh(x), 0.75, math.Ln2},
+		{symb.NodeAcosh(x), 1.25, math.Ln2},
+		{symb.NodeAcosh(x), 1.0, 0.0},
+		{symb.NodeAtanh(x), 0.6, math.Ln2},
+	}
+	for _, test := range tests {
+		x.SetValue(test.value)
+		if got := test.expr.Evaluate(); math.Abs(got-test.expected) > 1e-12 {
+			t.Error(test.expr, "at", test.value, "expected", test.expected, "got", got)
+		}
+	}
+
+	for _, input := range []string{"acosh(0.5)", "atanh(1)", "atanh(-1)", "atanh(2)"} {
+		expr, _ := symb.Parse(input)
+		_, err := expr.EvaluateErr()
+		var domain *symb.ErrDomain
+		if !errors.As(err, &domain) || domain.Func != input[:5] {
+			t.Error(input, "expected a domain error, got", err)
+		}
+	}
+}
+
+func TestDiffWithSinh(t *testing.T) {
+	x := symb.CreateVariable("x")
+	two := symb.GetConstantValue(2.0)
+	sinh := symb.NodeSinh(
+		symb.NodeMultiply(two, x),
+	)
+
+	expr := sinh.Diff(x).String()
+	if expr != "(cosh((2 * x)) * (2 * 1))" {
+		t.Error("Expected (cosh((2 * x)) * (2 * 1)), got", expr)
+	}
+}
+
+func TestDiffWithCosh(t *testing.T) {
+	x := symb.CreateVariable("x")
+	two := symb.GetConstantValue(2.0)
+	cosh := symb.NodeCosh(
+		symb.NodeMultiply(two, x),
+	)
+
+	expr := cosh.Diff(x).String()
+	if expr != "(sinh((2 * x)) * (2 * 1))" {
+		t.Error("Expected (sinh((2 * x)) * (2 * 1)), got", expr)
+	}
+}
+
+func TestDiffWithHyperbolic(t *testing.T) {
+	x := symb.CreateVariable("x")
+	for _, input := range []string{"tanh(x)", "asinh(x)", "acosh(x + 2)", "atanh(x)"} {
+		f, _ := symb.ParseWith(input, x)
+		derivative := f.Diff(x)
+		for _, value := range []float64{-0.6, 0.3, 0.8} {
+			h := 1e-6
+			at := func(v float64) float64 {
+				result, _ := f.EvaluateWith(symb.Env{"x": v})
+				return result
+			}
+			want := (at(value+h) - at(value-h)) / (2.0 * h)
+			got, err := derivative.EvaluateWith(symb.Env{"x": value})
+			if err != nil || math.Abs(got-want) > 1e-6 {
+				t.Error("d/dx", input, "at", value, "expected", want, "got", got, err)
+			}
+		}
+	}
+}
+
+func TestTrimWithHyperbolic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sinh(0) + x", "x"},
+		{"cosh(0) * x", "x"},
+		{"tanh(0) + asinh(0) + acosh(1) + atanh(0)", "0"},
+		{"cosh(1000) * x", "cosh(1000) * x"},
+		{"acosh(0.5)", "acosh(0.5)"},
+		{"atanh(1)", "atanh(1)"},
+		{"sinh(x * 0)", "0"},
+	}
+	for _, test := range tests {
+		expr, err := symb.Parse(test.input)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if got := symb.Format(expr.Trim()); got != test.expected {
+			t.Error(test.input, "expected", test.expected, "got", got)
+		}
+	}
+}
+
+func TestNodeLog(t *testing.T) {
+	x := symb.CreateVariable("x")
+	tests := []struct {
+		expr     symb.Evaluatable
+		value    float64
+		expected float64
+	}{
+		{symb.NodeLog10(x), 1000.0, 3.0},
+		{symb.NodeLog2(x), 0.125, -3.0},
+		{symb.NodeLog(symb.GetConstantValue(3), x), 81.0, 4.0},
+		{symb.NodeLog(x, symb.GetConstantValue(8)), 4.0, 1.5},
+	}
+	for _, test := range tests {
+		x.SetValue(test.value)
+		if got := test.expr.Evaluate(); math.Abs(got-test.expected) > 1e-12 {
+			t.Error(test.expr, "at", test.value, "expected", test.expected, "got", got)
+		}
+	}
+
+	for _, input := range []string{"log10(0)", "log(1, 5)", "log(-2, 5)", "log2(-1)"} {
+		expr, _ := symb.Parse(input)
+		_, err := expr.EvaluateErr()
+		var domain *symb.ErrDomain
+		if !errors.As(err, &domain) || domain.Func != "log" {
+			t.Error(input, "expected a domain error of log, got", err)
+		}
+	}
+}
+
+func TestDiffWithLog(t *testing.T) {
+	x := symb.CreateVariable("x")
+	two := symb.GetConstantValue(2.0)
+	log := symb.NodeLog10(
+		symb.NodeMultiply(two, x),
+	)
+
+	expr := log.Diff(x).String()
+	if expr != "((1 / ((2 * x) * ln(10))) * (2 * 1))" {
+		t.Error("Expected ((1 / ((2 * x) * ln(10))) * (2 * 1)), got", expr)
+	}
+
+	// A variable base goes through the change of base
+	y := symb.CreateVariable("y")
+	f, _ := symb.ParseWith("log(x, y ^ 2)", x, y)
+	env := symb.Env{"x": 3.0, "y": 1.5}
+	for _, v := range []*symb.Variable{x, y} {
+		at := func(delta float64) float64 {
+			shifted := symb.Env{"x": env["x"], "y": env["y"]}
+			shifted[v.String()] += delta
+			value, _ := f.EvaluateWith(shifted)
+			return value
+		}
+		want := (at(1e-6) - at(-1e-6)) / 2e-6
+		if got, _ := f.Diff(v).EvaluateWith(env); math.Abs(got-want) > 1e-6 {
+			t.Error("d/d"+v.String(), "expected", want, "got", got)
+		}
+	}
+}
+
+func TestTrimWithLog(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"log(3, 1) + x", "x"},
+		{"log(x, x)", "1"},
+		{"log10(1000)", "3"},
+		{"log(2, 2 ^ (x + 1))", "x + 1"},
+		{"log(e, x)", "ln(x)"},
+		{"log(e, e ^ x)", "x"},
+		{"ln(exp(x * y))", "x * y"},
+		{"exp(ln(x))", "exp(ln(x))"},
+		{"log(1, 5)", "log(1, 5)"},
+	}
+	for _, test := range tests {
+		expr, err := symb.Parse(test.input)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if got := symb.Format(expr.Trim()); got != test.expected {
+			t.Error(test.input, "expected", test.expected, "got", got)
+		}
+	}
+}
+
+func TestExpandLog(t *testing.T) {
+	tests := []struct {
+		input    string
+		expanded string
+	}{
+		{"log(10, x / y)", "log(10, x) - log(10, y)"},
+		{"sin(ln(x ^ n))", "sin(n * ln(x))"},
+	}
+	for _, test := range tests {
+		expr, _ := symb.Parse(test.input)
+		if got := symb.Format(symb.ExpandLog(expr)); got != test.expanded {
+			t.Error(test.input, "expected", test.expanded, "got", got)
+		}
+	}
+
+	expr, _ := symb.Parse("ln(x * y ^ 2 / z)")
+	expanded := symb.ExpandLog(expr)
+	if got := symb.Format(expanded); got != "ln(x) + 2 * ln(y) - ln(z)" {
+		t.Error("Expected ln(x) + 2 * ln(y) - ln(z), got", got)
+	}
+	if got := symb.Format(symb.ContractLog(expanded)); got != "ln(x * y ^ 2 / z)" {
+		t.Error("Expected ln(x * y ^ 2 / z), got", got)
+	}
+
+	// Logarithms in different bases are left alone
+	mixed, _ := symb.Parse("log(2, x) + log(3, y) + ln(z)")
+	if got := symb.Format(symb.ContractLog(mixed)); got != "log(2, x) + log(3, y) + ln(z)" {
+		t.Error("Expected the logarithms to stay apart, got", got)
+	}
+	same, _ := symb.Parse("log(2, x) - 3 * log(2, y)")
+	if got := symb.Format(symb.ContractLog(same)); got != "log(2, x / y ^ 3)" {
+		t.Error("Expected log(2, x / y ^ 3), got", got)
+	}
+}
+
+func TestCancelLog(t *testing.T) {
+	expr, _ := symb.Parse("sin(exp(ln(x * y))) + exp(ln(exp(ln(z))))")
+	if got := symb.Format(symb.CancelLog(expr)); got != "sin(x * y) + z" {
+		t.Error("Expected sin(x * y) + z, got", got)
+	}
+
+	// Only on request, exp(ln(x)) is not defined for negative x
+	x := symb.CreateVariable("x")
+	cancel := symb.NodeExp(symb.NodeLn(x))
+	var domainErr *symb.ErrDomain
+	if _, err := cancel.Trim().EvaluateWith(symb.Env{"x": -1.0}); !errors.As(err, &domainErr) {
+		t.Error("Expected an ErrDomain, got", err)
+	}
+}
+
+func TestParseLog(t *testing.T) {
+	expr, err := symb.Parse("log(b, x) + log10(x) * log2(x)")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := symb.Format(expr); got != "log(b, x) + log(10, x) * log(2, x)" {
+		t.Error("Expected log(b, x) + log(10, x) * log(2, x), got", got)
+	}
+	if got := symb.LaTeX(expr); !strings.HasPrefix(got, "\\log_{b}\\left(x\\right)") {
+		t.Error("Unexpected LaTeX:", got)
+	}
+}
+
+func TestNodeNeg(t *testing.T) {
+	x := symb.CreateVariable("x")
+	neg := symb.NodeNeg(x)
+
+	x.SetValue(2.5)
+	if got := neg.Evaluate(); got != -2.5 {
+		t.Error("Expected -2.5, got", got)
+	}
+	if got := neg.String(); got != "(-x)" {
+		t.Error("Expected (-x), got", got)
+	}
+	if got := symb.Format(symb.NodeNeg(symb.NodeAdd(x, x))); got != "-(x + x)" {
+		t.Error("Expected -(x + x), got", got)
+	}
+	if got := symb.LaTeX(symb.NodeNeg(symb.NodeSin(x))); got != "-\\sin\\left(x\\right)" {
+		t.Error("Expected -\\sin\\left(x\\right), got", got)
+	}
+
+	program, _ := symb.Compile(symb.NodeAdd(neg, symb.NodeNeg(symb.NodeSin(x))), []*symb.Variable{x})
+	if got, want := program.Eval([]float64{0.5}), -0.5-math.Sin(0.5); got != want {
+		t.Error("Expected", want, "got", got)
+	}
+}
+
+func TestDiffWithNeg(t *testing.T) {
+	x := symb.CreateVariable("x")
+	two := symb.GetConstantValue(2.0)
+	neg := symb.NodeNeg(
+		symb.NodeMultiply(two, x),
+	)
+
+	expr := neg.Diff(x).String()
+	if expr != "(-(2 * 1))" {
+		t.Error("Expected (-(2 * 1)), got", expr)
+	}
+	if got := neg.Diff(symb.CreateVariable("y")).String(); got != "0" {
+		t.Error("Expected 0, got", got)
+	}
+}
+
+func TestTrimWithNeg(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"--x", "x"},
+		{"-(-(-x))", "(-x)"},
+		{"-2", "-2"},
+		{"-(3 - 1)", "-2"},
+		{"-(x * 0)", "0"},
+		{"x + -y", "(x - y)"},
+		{"-x + y", "(y - x)"},
+		{"x - -y", "(x + y)"},
+		{"-1 * sin(x)", "(-sin(x))"},
+		{"sin(x) * (0 - 1)", "(-sin(x))"},
+	}
+	for _, test := range tests {
+		expr, err := symb.Parse(test.input)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if got := expr.Trim().String(); got != test.expected {
+			t.Error("Trimming", test.input, "expected", test.expected, "got", got)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+#include <math.h>
+
+static double sign(double x)
+{
+    if (x > 0.0) {
+        return 1.0;
+    } else if (x < 0.0) {
+        return -1.0;
+    }
+    return x;
+}
+
+double add(double x, double y)
+{
+    return x + y;
+}
+
+double sub(double x, double y)
+{
+    return x - y;
+}
+
+double multiply(double x, double y)
+{
+    return x * y;
+}
+
+double divide(double x, double y)
+{
+    return x / y;
+}
+
+double negate(double x, double y)
+{
+    return -(x * y);
+}
+
+double power(double x, double y)
+{
+    return pow(x, y);
+}
+
+double powerproduct(double x, double y)
+{
+    return pow(x, x * y);
+}
+
+double powerscaled(double x, double y)
+{
+    return pow(x, (-2.0) * y);
+}
+
+double ln(double x, double y)
+{
+    return log(x);
+}
+
+double sine(double x, double y)
+{
+    return sin(x);
+}
+
+double cosine(double x, double y)
+{
+    return cos(y);
+}
+
+double tangent(double x, double y)
+{
+    return tan(x);
+}
+
+double exponential(double x, double y)
+{
+    return exp(x);
+}
+
+double root(double x, double y)
+{
+    return sqrt(x);
+}
+
+double absolute(double x, double y)
+{
+    return fabs(x);
+}
+
+double signum(double x, double y)
+{
+    return sign(x);
+}
+
+double arcsine(double x, double y)
+{
+    return asin(x);
+}
+
+double arccosine(double x, double y)
+{
+    return acos(x);
+}
+
+double arctangent(double x, double y)
+{
+    return atan(x);
+}
+
+double angle(double x, double y)
+{
+    return atan2(y, x);
+}
+
+double hsine(double x, double y)
+{
+    return sinh(x);
+}
+
+double hcosine(double x, double y)
+{
+    return cosh(x);
+}
+
+double htangent(double x, double y)
+{
+    return tanh(x);
+}
+
+double areasine(double x, double y)
+{
+    return asinh(x);
+}
+
+double areacosine(double x, double y)
+{
+    return acosh(x);
+}
+
+double areatangent(double x, double y)
+{
+    return atanh(x);
+}
+
+double decimal(double x, double y)
+{
+    return log10(x);
+}
+
+double binary(double x, double y)
+{
+    return log2(x);
+}
+
+double logarithm(double x, double y)
+{
+    return log(x) / log(y);
+}
+
+double nested(double x, double y)
+{
+    return (x - (y - 1.0)) / (x * (-2.0)) - pow(x, pow(y, 2.0)) + pow(-2.0, x) * 3.141592653589793 / 2.718281828459045;
+}
+
+/* Fills out with the 2 outputs */
+void gradient(double x, double y, double *out)
+{
+    const double t0 = sin(y);
+    const double t1 = cos(y);
+    out[0] = 2.0 * x * t0 + (-t0) / (x * x);
+    out[1] = pow(x, 2.0) * t1 + t1 / x;
+}
+
+/* Fills out with the 2x2 matrix, row by row */
+void jacobian(double x, double y, double *out)
+{
+    const double t0 = sin(y);
+    const double t1 = cos(y);
+    const double t2 = 1.0 / (x * y);
+    out[0] = 2.0 * x * t0 + (-t0) / (x * x);
+    out[1] = pow(x, 2.0) * t1 + t1 / x;
+    out[2] = t2 * y;
+    out[3] = t2 * x;
+}
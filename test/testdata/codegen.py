@@ -0,0 +1,134 @@
+import numpy as np
+
+
+def add(x, y):
+    return x + y
+
+
+def sub(x, y):
+    return x - y
+
+
+def multiply(x, y):
+    return x * y
+
+
+def divide(x, y):
+    return x / y
+
+
+def negate(x, y):
+    return -(x * y)
+
+
+def power(x, y):
+    return x ** y
+
+
+def powerproduct(x, y):
+    return x ** (x * y)
+
+
+def powerscaled(x, y):
+    return x ** ((-2.0) * y)
+
+
+def ln(x, y):
+    return np.log(x)
+
+
+def sine(x, y):
+    return np.sin(x)
+
+
+def cosine(x, y):
+    return np.cos(y)
+
+
+def tangent(x, y):
+    return np.tan(x)
+
+
+def exponential(x, y):
+    return np.exp(x)
+
+
+def root(x, y):
+    return np.sqrt(x)
+
+
+def absolute(x, y):
+    return np.abs(x)
+
+
+def signum(x, y):
+    return np.sign(x)
+
+
+def arcsine(x, y):
+    return np.arcsin(x)
+
+
+def arccosine(x, y):
+    return np.arccos(x)
+
+
+def arctangent(x, y):
+    return np.arctan(x)
+
+
+def angle(x, y):
+    return np.arctan2(y, x)
+
+
+def hsine(x, y):
+    return np.sinh(x)
+
+
+def hcosine(x, y):
+    return np.cosh(x)
+
+
+def htangent(x, y):
+    return np.tanh(x)
+
+
+def areasine(x, y):
+    return np.arcsinh(x)
+
+
+def areacosine(x, y):
+    return np.arccosh(x)
+
+
+def areatangent(x, y):
+    return np.arctanh(x)
+
+
+def decimal(x, y):
+    return np.log10(x)
+
+
+def binary(x, y):
+    return np.log2(x)
+
+
+def logarithm(x, y):
+    return np.log(x) / np.log(y)
+
+
+def nested(x, y):
+    return (x - (y - 1.0)) / (x * (-2.0)) - x ** y ** 2.0 + (-2.0) ** x * np.pi / np.e
+
+
+def gradient(x, y):
+    t0 = np.sin(y)
+    t1 = np.cos(y)
+    return np.array([2.0 * x * t0 + (-t0) / (x * x), x ** 2.0 * t1 + t1 / x])
+
+
+def jacobian(x, y):
+    t0 = np.sin(y)
+    t1 = np.cos(y)
+    t2 = 1.0 / (x * y)
+    return np.array([[2.0 * x * t0 + (-t0) / (x * x), x ** 2.0 * t1 + t1 / x], [t2 * y, t2 * x]])